@@ -1,17 +1,31 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/checker"
+	"github.com/fadion/aria/dap"
+	"github.com/fadion/aria/debugger"
+	"github.com/fadion/aria/doc"
+	"github.com/fadion/aria/format"
 	"github.com/fadion/aria/interpreter"
 	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/lsp"
 	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/profiler"
 	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/repl"
 	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/tester"
+	"github.com/fadion/aria/token"
 	"github.com/fatih/color"
 	"github.com/urfave/cli"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
@@ -28,12 +42,35 @@ func main() {
 		{
 			Name:  "run",
 			Usage: "Run an Aria source file",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "graphemes",
+					Usage: "Treat grapheme clusters as string characters, instead of runes",
+				},
+				cli.StringFlag{
+					Name:  "profile",
+					Usage: "Write a pprof profile of the Aria call stack to `FILE`",
+				},
+				cli.BoolFlag{
+					Name:  "trace-calls",
+					Usage: "Report call counts and cumulative time per function",
+				},
+			},
+			ArgsUsage: "file [-- arguments...]",
 			Action: func(c *cli.Context) error {
-				if len(c.Args()) != 1 {
-					color.Red("Run expects a source file as argument.")
+				// Arguments of the script follow a "--".
+				args := c.Args()
+				if len(args) == 0 || (len(args) > 1 && args[1] != "--") {
+					color.Red("Run expects a source file as argument, with any arguments of the script after '--'.")
+					return nil
+				}
+
+				file := args[0]
+				scriptArgs := []string{}
+				if len(args) > 1 {
+					scriptArgs = args[2:]
 				}
 
-				file := c.Args()[0]
 				source, err := ioutil.ReadFile(file)
 				if err != nil {
 					color.Red("Couldn't read '%s'", file)
@@ -54,60 +91,469 @@ func main() {
 				}
 
 				runner := interpreter.New()
+				runner.SetGraphemes(c.Bool("graphemes"))
+				runner.SetFile(file)
+				runner.SetArgs(scriptArgs)
+
+				// OS.exit() stops the program, so the profile
+				// and errors are still written.
+				exited, exitCode := false, 0
+				runner.SetExit(func(code int) {
+					exited, exitCode = true, code
+				})
+
+				var prof *profiler.Profiler
+				if c.String("profile") != "" || c.Bool("trace-calls") {
+					prof = profiler.New(runner)
+					prof.Start()
+				}
+
 				runner.Interpret(program, interpreter.NewScope())
+
+				if prof != nil {
+					prof.Stop()
+					writeProfile(prof, c.String("profile"), c.Bool("trace-calls"))
+				}
+
 				if reporter.HasErrors() {
 					printErrors()
 					return nil
 				}
 
+				if exited {
+					return cli.NewExitError("", exitCode)
+				}
+
 				return nil
 			},
 		},
 		{
-			Name:  "repl",
-			Usage: "Start the interactive repl",
+			Name:      "fmt",
+			Usage:     "Format Aria source files in place",
+			ArgsUsage: "[files or directories...]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "Only list files that aren't formatted, failing if there are any",
+				},
+				cli.BoolFlag{
+					Name:  "diff",
+					Usage: "Print the changes instead of writing them",
+				},
+			},
 			Action: func(c *cli.Context) error {
-				input := bufio.NewReader(os.Stdin)
-				color.Yellow(`    _   ___ ___   _
-   /_\ | _ \_ _| /_\
-  / _ \|   /| | / _ \
- /_/ \_\_|_\___/_/ \_\
- `)
-				color.White("Close by pressing CTRL+C")
-				fmt.Println()
+				if len(c.Args()) == 0 {
+					color.Red("Fmt expects source files or directories as arguments.")
+					return cli.NewExitError("", 2)
+				}
 
-				scope := interpreter.NewScope()
+				files, err := sourceFiles(c.Args())
+				if err != nil {
+					color.Red(err.Error())
+					return cli.NewExitError("", 2)
+				}
 
-				for {
-					color.Set(color.FgWhite)
-					fmt.Print(">> ")
-					color.Unset()
+				unformatted := false
+				for _, file := range files {
+					source, err := ioutil.ReadFile(file)
+					if err != nil {
+						color.Red("Couldn't read '%s'", file)
+						return cli.NewExitError("", 2)
+					}
 
-					source, _ := input.ReadBytes('\n')
-					lex := lexer.New(reader.New(source))
-					if reporter.HasErrors() {
+					formatted, err := format.Source(source)
+					if err != nil {
+						color.Red("Couldn't format '%s'", file)
 						printErrors()
+						return cli.NewExitError("", 2)
+					}
+
+					if bytes.Equal(source, formatted) {
 						continue
 					}
+					unformatted = true
 
-					parse := parser.New(lex)
-					program := parse.Parse()
-					if reporter.HasErrors() {
-						printErrors()
+					switch {
+					case c.Bool("diff"):
+						os.Stdout.Write(format.Diff(file, source, formatted))
+					case c.Bool("check"):
+						fmt.Println(file)
+					default:
+						if err := ioutil.WriteFile(file, formatted, 0644); err != nil {
+							color.Red("Couldn't write '%s'", file)
+							return cli.NewExitError("", 2)
+						}
+					}
+				}
+
+				// Checks and diffs are meant for hooks, so they
+				// fail when something needs formatting.
+				if unformatted && (c.Bool("check") || c.Bool("diff")) {
+					return cli.NewExitError("", 1)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "check",
+			Usage:     "Find mistakes in Aria source files without running them",
+			ArgsUsage: "[files or directories...]",
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) == 0 {
+					color.Red("Check expects source files or directories as arguments.")
+					return cli.NewExitError("", 2)
+				}
+
+				files, err := sourceFiles(c.Args())
+				if err != nil {
+					color.Red(err.Error())
+					return cli.NewExitError("", 2)
+				}
+
+				check := checker.New()
+				for _, file := range files {
+					check.CheckFile(file)
+				}
+
+				diagnostics := check.Diagnostics()
+				for _, diagnostic := range diagnostics {
+					fmt.Println(diagnostic)
+				}
+
+				if len(diagnostics) > 0 {
+					return cli.NewExitError("", 1)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "tokens",
+			Usage:     "Print the tokens of an Aria source file",
+			ArgsUsage: "file",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print the tokens as JSON",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) != 1 {
+					color.Red("Tokens expects a source file as argument.")
+					return cli.NewExitError("", 2)
+				}
+
+				file := c.Args()[0]
+				source, err := ioutil.ReadFile(file)
+				if err != nil {
+					color.Red("Couldn't read '%s'", file)
+					return cli.NewExitError("", 2)
+				}
+
+				tokens := []token.Token{}
+				lex := lexer.New(reader.New(source))
+				for {
+					tok := lex.NextToken()
+					tokens = append(tokens, tok)
+					if tok.Type == token.EOF {
+						break
+					}
+				}
+
+				if c.Bool("json") {
+					printJSON(tokens)
+				} else {
+					for _, tok := range tokens {
+						fmt.Printf("%-7s %-12s %q\n", fmt.Sprintf("%d:%d", tok.Location.Row, tok.Location.Col), tok.Type, tok.Lexeme)
+					}
+				}
+
+				if reporter.HasErrors() {
+					printErrors()
+					return cli.NewExitError("", 1)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "ast",
+			Usage:     "Print the syntax tree of an Aria source file",
+			ArgsUsage: "file",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print the syntax tree as JSON",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) != 1 {
+					color.Red("Ast expects a source file as argument.")
+					return cli.NewExitError("", 2)
+				}
+
+				file := c.Args()[0]
+				source, err := ioutil.ReadFile(file)
+				if err != nil {
+					color.Red("Couldn't read '%s'", file)
+					return cli.NewExitError("", 2)
+				}
+
+				program := parser.New(lexer.New(reader.New(source))).Parse()
+				if reporter.HasErrors() {
+					printErrors()
+					return cli.NewExitError("", 1)
+				}
+
+				if c.Bool("json") {
+					printJSON(ast.NewDump(program))
+				} else {
+					fmt.Print(ast.NewDump(program))
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "doc",
+			Usage:     "Generate reference pages from doc comments",
+			ArgsUsage: "[files or directories...]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "library",
+					Usage: "Include the Standard Library, the default without arguments",
+				},
+				cli.BoolFlag{
+					Name:  "html",
+					Usage: "Write HTML instead of Markdown",
+				},
+				cli.StringFlag{
+					Name:  "out",
+					Usage: "Write a page per file to `DIR` instead of printing them",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				files, err := sourceFiles(c.Args())
+				if err != nil {
+					color.Red(err.Error())
+					return cli.NewExitError("", 2)
+				}
+
+				pages := []*doc.Page{}
+				names := []string{}
+				if c.Bool("library") || len(c.Args()) == 0 {
+					page, err := doc.Library()
+					if err != nil {
+						color.Red(err.Error())
+						return cli.NewExitError("", 2)
+					}
+					pages = append(pages, page)
+					names = append(names, "library")
+				}
+
+				for _, file := range files {
+					source, err := ioutil.ReadFile(file)
+					if err != nil {
+						color.Red("Couldn't read '%s'", file)
+						return cli.NewExitError("", 2)
+					}
+
+					page, err := doc.Source(file, source)
+					if err != nil {
+						color.Red(err.Error())
+						return cli.NewExitError("", 2)
+					}
+					pages = append(pages, page)
+					names = append(names, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+				}
+
+				extension := ".md"
+				if c.Bool("html") {
+					extension = ".html"
+				}
+
+				for index, page := range pages {
+					var out bytes.Buffer
+					if c.Bool("html") {
+						doc.HTML(&out, page)
+					} else {
+						doc.Markdown(&out, page)
+					}
+
+					dir := c.String("out")
+					if dir == "" {
+						if index > 0 {
+							fmt.Println()
+						}
+						os.Stdout.Write(out.Bytes())
 						continue
 					}
 
-					runner := interpreter.New()
-					object := runner.Interpret(program, scope)
-					if reporter.HasErrors() {
-						printErrors()
+					file := filepath.Join(dir, names[index]+extension)
+					if err := os.MkdirAll(dir, 0755); err != nil {
+						color.Red("Couldn't write '%s'", file)
+						return cli.NewExitError("", 2)
+					}
+					if err := ioutil.WriteFile(file, out.Bytes(), 0644); err != nil {
+						color.Red("Couldn't write '%s'", file)
+						return cli.NewExitError("", 2)
+					}
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "test",
+			Usage:     "Run the tests in *_test.ari files",
+			ArgsUsage: "[files or directories...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "junit",
+					Usage: "Write the results as JUnit XML to `FILE`",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				paths := c.Args()
+				if len(paths) == 0 {
+					paths = []string{"."}
+				}
+
+				files, err := sourceFiles(paths)
+				if err != nil {
+					color.Red(err.Error())
+					return cli.NewExitError("", 2)
+				}
+
+				results := []tester.Result{}
+				for _, file := range files {
+					// Explicit files run whatever their name.
+					if !tester.IsTestFile(file) && !containsString(paths, file) {
+						continue
+					}
+
+					fileResults, err := tester.RunFile(file)
+					if err != nil {
+						color.Red(err.Error())
+						return cli.NewExitError("", 2)
+					}
+					results = append(results, fileResults...)
+				}
+
+				failed := 0
+				for _, result := range results {
+					if result.Passed() {
+						color.Green("PASS %s %s (%s)", result.File, result.Name, result.Duration)
 						continue
 					}
 
-					if object != nil {
-						fmt.Println(object.Inspect())
+					failed++
+					color.Red("FAIL %s %s (%s)", result.File, result.Name, result.Duration)
+					for _, failure := range result.Failures {
+						fmt.Println("    " + failure)
+					}
+				}
+
+				fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+
+				if file := c.String("junit"); file != "" {
+					out, err := os.Create(file)
+					if err != nil {
+						color.Red("Couldn't write '%s'", file)
+						return cli.NewExitError("", 2)
+					}
+					defer out.Close()
+
+					if err := tester.JUnit(out, results); err != nil {
+						color.Red("Couldn't write '%s'", file)
+						return cli.NewExitError("", 2)
 					}
 				}
+
+				if failed > 0 {
+					return cli.NewExitError("", 1)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "debug",
+			Usage:     "Run an Aria source file in the step debugger",
+			ArgsUsage: "file",
+			Flags: []cli.Flag{
+				cli.IntSliceFlag{
+					Name:  "break, b",
+					Usage: "Set a breakpoint at `LINE`, can be repeated",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) != 1 {
+					color.Red("Debug expects a source file as argument.")
+					return cli.NewExitError("", 2)
+				}
+
+				file := c.Args()[0]
+				source, err := ioutil.ReadFile(file)
+				if err != nil {
+					color.Red("Couldn't read '%s'", file)
+					return cli.NewExitError("", 2)
+				}
+
+				color.White("Paused before the first statement. Type help for commands.")
+
+				debug := debugger.New(file, os.Stdin, os.Stdout)
+				for _, line := range c.IntSlice("break") {
+					debug.Break(line)
+				}
+				debug.Run(source)
+
+				return nil
+			},
+		},
+		{
+			Name:  "dap",
+			Usage: "Start a debug adapter for editors, speaking DAP over stdio",
+			Action: func(c *cli.Context) error {
+				if err := dap.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return cli.NewExitError("", 1)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "lsp",
+			Usage: "Start a language server for editors, speaking LSP over stdio",
+			Action: func(c *cli.Context) error {
+				if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return cli.NewExitError("", 1)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "repl",
+			Usage: "Start the interactive repl",
+			Action: func(c *cli.Context) error {
+				color.Yellow(`    _   ___ ___   _
+   /_\ | _ \_ _| /_\
+  / _ \|   /| | / _ \
+ /_/ \_\_|_\___/_/ \_\
+ `)
+				color.White("Close by pressing CTRL+D. Type :help for commands.")
+				fmt.Println()
+
+				history := ""
+				if home, err := os.UserHomeDir(); err == nil {
+					history = filepath.Join(home, ".aria_history")
+				}
+
+				return repl.New(os.Stdin, os.Stdout, repl.NewHistory(history)).Run()
 			},
 		},
 	}
@@ -119,6 +565,76 @@ func main() {
 	app.Run(os.Args)
 }
 
+// Expand directories to the Aria source files
+// they contain, recursively.
+func sourceFiles(paths []string) ([]string, error) {
+	files := []string{}
+
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return fmt.Errorf("Couldn't read '%s'", file)
+			}
+
+			// Explicit files are formatted whatever
+			// their extension.
+			if file == path && !info.IsDir() || !info.IsDir() && filepath.Ext(file) == ".ari" {
+				files = append(files, file)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// Write the profile to a file and the report of
+// calls to stderr, as requested.
+func writeProfile(prof *profiler.Profiler, file string, traceCalls bool) {
+	if file != "" {
+		out, err := os.Create(file)
+		if err != nil {
+			color.Red("Couldn't write '%s'", file)
+		} else {
+			if err := prof.WriteProfile(out); err != nil {
+				color.Red("Couldn't write '%s'", file)
+			}
+			out.Close()
+		}
+	}
+
+	if traceCalls {
+		prof.WriteCalls(os.Stderr)
+	}
+}
+
+// Print a value as indented JSON.
+func printJSON(value interface{}) {
+	out, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		color.Red(err.Error())
+		return
+	}
+
+	fmt.Println(string(out))
+}
+
+// Check if a list of strings contains one.
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
+
 func printErrors() {
 	color.White("Oops, found some errors:")
 	for _, v := range reporter.GetErrors() {
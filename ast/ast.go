@@ -3,7 +3,9 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"github.com/fadion/aria/decimal"
 	"github.com/fadion/aria/token"
+	"math/big"
 	"strings"
 )
 
@@ -58,6 +60,7 @@ type Let struct {
 	Token token.Token
 	Name  *Identifier
 	Value Expression
+	Doc   string
 }
 
 func (e *Let) expression()                   {}
@@ -124,6 +127,17 @@ func (e *String) TokenLexeme() string           { return e.Token.Lexeme }
 func (e *String) TokenLocation() token.Location { return e.Token.Location }
 func (e *String) Inspect() string               { return e.Token.Lexeme }
 
+// Regex literal, with its pattern.
+type Regex struct {
+	Token token.Token
+	Value string
+}
+
+func (e *Regex) expression()                   {}
+func (e *Regex) TokenLexeme() string           { return e.Token.Lexeme }
+func (e *Regex) TokenLocation() token.Location { return e.Token.Location }
+func (e *Regex) Inspect() string               { return "~r\"" + strings.Replace(e.Value, "\"", "\\\"", -1) + "\"" }
+
 // Atom literal.
 type Atom struct {
 	Token token.Token
@@ -135,10 +149,12 @@ func (e *Atom) TokenLexeme() string           { return e.Token.Lexeme }
 func (e *Atom) TokenLocation() token.Location { return e.Token.Location }
 func (e *Atom) Inspect() string               { return ":" + e.Token.Lexeme }
 
-// Integer numeric literal.
+// Integer numeric literal. Literals that don't
+// fit in 64 bits are kept in Big.
 type Integer struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int
 }
 
 func (e *Integer) expression()                   {}
@@ -157,6 +173,17 @@ func (e *Float) TokenLexeme() string           { return e.Token.Lexeme }
 func (e *Float) TokenLocation() token.Location { return e.Token.Location }
 func (e *Float) Inspect() string               { return e.Token.Lexeme }
 
+// Decimal literal for exact base 10 numbers.
+type Decimal struct {
+	Token token.Token
+	Value decimal.Decimal
+}
+
+func (e *Decimal) expression()                   {}
+func (e *Decimal) TokenLexeme() string           { return e.Token.Lexeme }
+func (e *Decimal) TokenLocation() token.Location { return e.Token.Location }
+func (e *Decimal) Inspect() string               { return e.Token.Lexeme + "d" }
+
 // Boolean literal.
 type Boolean struct {
 	Token token.Token
@@ -187,6 +214,25 @@ func (e *Array) Inspect() string {
 	return out.String()
 }
 
+// Set literal.
+type Set struct {
+	Token token.Token
+	List  *ExpressionList
+}
+
+func (e *Set) expression()                   {}
+func (e *Set) TokenLexeme() string           { return e.Token.Lexeme }
+func (e *Set) TokenLocation() token.Location { return e.Token.Location }
+func (e *Set) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString("Set(")
+	out.WriteString(e.List.Inspect())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // Subscript for arrays and dictionaries.
 type Subscript struct {
 	Token token.Token
@@ -208,6 +254,36 @@ func (e *Subscript) Inspect() string {
 	return out.String()
 }
 
+// Slice as the index of a subscript: START..END..STEP.
+// Any of the parts may be missing.
+type Slice struct {
+	Token token.Token
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (e *Slice) expression()                   {}
+func (e *Slice) TokenLexeme() string           { return e.Token.Lexeme }
+func (e *Slice) TokenLocation() token.Location { return e.Token.Location }
+func (e *Slice) Inspect() string {
+	var out bytes.Buffer
+
+	if e.Start != nil {
+		out.WriteString(e.Start.Inspect())
+	}
+	out.WriteString("..")
+	if e.End != nil {
+		out.WriteString(e.End.Inspect())
+	}
+	if e.Step != nil {
+		out.WriteString("..")
+		out.WriteString(e.Step.Inspect())
+	}
+
+	return out.String()
+}
+
 // Subscript for arrays and dictionaries.
 type Assign struct {
 	Token    token.Token
@@ -249,10 +325,11 @@ func (e *Pipe) Inspect() string {
 	return out.String()
 }
 
-// Dictionary literal.
+// Dictionary literal. Pairs are kept in the
+// order they were written.
 type Dictionary struct {
 	Token token.Token
-	Pairs map[Expression]Expression
+	Pairs []*DictionaryPair
 }
 
 func (e *Dictionary) expression()                   {}
@@ -262,8 +339,8 @@ func (e *Dictionary) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range e.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s => %s", key.Inspect(), value.Inspect()))
+	for _, pair := range e.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s => %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
 	out.WriteString("[")
@@ -273,6 +350,13 @@ func (e *Dictionary) Inspect() string {
 	return out.String()
 }
 
+// DictionaryPair holds a key and value of
+// a Dictionary literal.
+type DictionaryPair struct {
+	Key   Expression
+	Value Expression
+}
+
 // Nil type.
 type Nil struct {
 	Token token.Token
@@ -425,6 +509,7 @@ type Module struct {
 	Token token.Token
 	Name  *Identifier
 	Body  *BlockStatement
+	Doc   string
 }
 
 func (e *Module) expression()                   {}
@@ -664,10 +749,12 @@ func (e *ExpressionStatement) Inspect() string {
 	return ""
 }
 
-// BlockStatement that holds several statements.
+// BlockStatement that holds several statements. End is
+// the token that closed the block, like an END or ELSE.
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	End        token.Token
 }
 
 func (e *BlockStatement) statement()                    {}
@@ -0,0 +1,203 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fadion/aria/decimal"
+	"github.com/fadion/aria/token"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Dump is a node described with plain values, meant
+// for tooling and for debugging the parser. Unlike
+// Inspect, it keeps the kind and location of every
+// node.
+type Dump struct {
+	Kind     string
+	Location token.Location
+	Fields   []DumpField
+}
+
+// DumpField is a field of a dumped node, in the
+// order it's declared. Its value is a *Dump, a
+// slice or map of them, or a scalar.
+type DumpField struct {
+	Name  string
+	Value interface{}
+}
+
+// Import path of the package, to tell its structs
+// apart from those of others.
+var astPackage = reflect.TypeOf(Dump{}).PkgPath()
+
+// NewDump describes a node and everything under it.
+func NewDump(node Node) *Dump {
+	return dumpStruct(reflect.ValueOf(node).Elem(), node.TokenLocation())
+}
+
+// Describe the fields of a struct under the given
+// location.
+func dumpStruct(fields reflect.Value, location token.Location) *Dump {
+	dump := &Dump{Kind: fields.Type().Name(), Location: location}
+
+	for index := 0; index < fields.NumField(); index++ {
+		if field, ok := dumpValue(fields.Field(index)); ok {
+			dump.Fields = append(dump.Fields, DumpField{Name: lowerFirst(fields.Type().Field(index).Name), Value: field})
+		}
+	}
+
+	return dump
+}
+
+// Describe an AST struct that isn't a node, like a
+// DictionaryPair. Without a token of its own, it takes
+// the location of its first child node.
+func dumpPart(fields reflect.Value) *Dump {
+	dump := dumpStruct(fields, token.Location{})
+	for _, field := range dump.Fields {
+		if child, ok := field.Value.(*Dump); ok {
+			dump.Location = child.Location
+			break
+		}
+	}
+
+	return dump
+}
+
+// Describe a field, reporting false for those left
+// out, like tokens and empty values.
+func dumpValue(value reflect.Value) (interface{}, bool) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		if value.IsNil() {
+			return nil, false
+		}
+	}
+
+	switch field := value.Interface().(type) {
+	case Node:
+		return NewDump(field), true
+	case token.Token:
+		return nil, false
+	case decimal.Decimal:
+		return field.String(), true
+	}
+
+	if value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Struct && value.Elem().Type().PkgPath() == astPackage {
+		return dumpPart(value.Elem()), true
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		if value.Len() == 0 {
+			return nil, false
+		}
+	case reflect.Slice:
+		items := []interface{}{}
+		for index := 0; index < value.Len(); index++ {
+			item, _ := dumpValue(value.Index(index))
+			items = append(items, item)
+		}
+		return items, true
+	case reflect.Map:
+		items := map[string]interface{}{}
+		for _, key := range value.MapKeys() {
+			item, _ := dumpValue(value.MapIndex(key))
+			items[fmt.Sprint(key.Interface())] = item
+		}
+		return items, true
+	}
+
+	return value.Interface(), true
+}
+
+// MarshalJSON serializes the node as an object with
+// its kind, location and fields, in that order.
+func (d *Dump) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+
+	location, err := json.Marshal(d.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(&out, `{"kind":%q,"location":%s`, d.Kind, location)
+	for _, field := range d.Fields {
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, ",%q:%s", field.Name, value)
+	}
+	out.WriteString("}")
+
+	return out.Bytes(), nil
+}
+
+// String prints the node as an indented tree, with
+// a line for each node.
+func (d *Dump) String() string {
+	var out bytes.Buffer
+	d.writeTree(&out, "", 0)
+	return out.String()
+}
+
+func (d *Dump) writeTree(out *bytes.Buffer, label string, depth int) {
+	out.WriteString(strings.Repeat("  ", depth) + label)
+	fmt.Fprintf(out, "%s %d:%d", d.Kind, d.Location.Row, d.Location.Col)
+
+	// Scalars go on the node's line, children on
+	// their own lines below.
+	children := []DumpField{}
+	for _, field := range d.Fields {
+		switch value := field.Value.(type) {
+		case *Dump, []interface{}, map[string]interface{}:
+			children = append(children, field)
+		case string:
+			fmt.Fprintf(out, " %s=%q", field.Name, value)
+		default:
+			fmt.Fprintf(out, " %s=%v", field.Name, value)
+		}
+	}
+	out.WriteString("\n")
+
+	for _, field := range children {
+		switch value := field.Value.(type) {
+		case *Dump:
+			value.writeTree(out, field.Name+": ", depth+1)
+		case []interface{}:
+			for index, item := range value {
+				writeTreeItem(out, fmt.Sprintf("%s[%d]: ", field.Name, index), item, depth+1)
+			}
+		case map[string]interface{}:
+			keys := []string{}
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				writeTreeItem(out, fmt.Sprintf("%s[%q]: ", field.Name, key), value[key], depth+1)
+			}
+		}
+	}
+}
+
+func writeTreeItem(out *bytes.Buffer, label string, item interface{}, depth int) {
+	if node, ok := item.(*Dump); ok {
+		node.writeTree(out, label, depth)
+		return
+	}
+
+	fmt.Fprintf(out, "%s%s%v\n", strings.Repeat("  ", depth), label, item)
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
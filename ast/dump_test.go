@@ -0,0 +1,100 @@
+package ast
+
+import (
+	"encoding/json"
+	"github.com/fadion/aria/token"
+	"math/big"
+	"testing"
+)
+
+func testProgram() *Program {
+	return &Program{Statements: []Statement{
+		&ExpressionStatement{
+			Token: token.Token{Type: token.LET, Lexeme: "let", Location: token.Location{Row: 1, Col: 1}},
+			Expression: &Let{
+				Token: token.Token{Type: token.LET, Lexeme: "let", Location: token.Location{Row: 1, Col: 1}},
+				Name:  &Identifier{Token: token.Token{Type: token.IDENTIFIER, Lexeme: "x", Location: token.Location{Row: 1, Col: 5}}, Value: "x"},
+				Value: &Array{
+					Token: token.Token{Type: token.LBRACK, Lexeme: "[", Location: token.Location{Row: 1, Col: 9}},
+					List: &ExpressionList{Token: token.Token{Type: token.LBRACK, Lexeme: "[", Location: token.Location{Row: 1, Col: 9}}, Elements: []Expression{
+						&Integer{Token: token.Token{Type: token.INTEGER, Lexeme: "1", Location: token.Location{Row: 1, Col: 10}}, Value: 1},
+						&Integer{Token: token.Token{Type: token.INTEGER, Lexeme: "2", Location: token.Location{Row: 1, Col: 13}}, Big: big.NewInt(2)},
+					}},
+				},
+			},
+		},
+	}}
+}
+
+func TestDumpTree(t *testing.T) {
+	expected := `Program 0:0
+  statements[0]: ExpressionStatement 1:1
+    expression: Let 1:1
+      name: Identifier 1:5 value="x"
+      value: Array 1:9
+        list: ExpressionList 1:9
+          elements[0]: Integer 1:10 value=1
+          elements[1]: Integer 1:13 value=0 big=2
+`
+
+	if actual := NewDump(testProgram()).String(); actual != expected {
+		t.Errorf("Expected tree:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestDumpJSON(t *testing.T) {
+	out, err := json.Marshal(NewDump(testProgram()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"kind":"Program","location":{"row":0,"col":0},"statements":[` +
+		`{"kind":"ExpressionStatement","location":{"row":1,"col":1},"expression":` +
+		`{"kind":"Let","location":{"row":1,"col":1},` +
+		`"name":{"kind":"Identifier","location":{"row":1,"col":5},"value":"x"},` +
+		`"value":{"kind":"Array","location":{"row":1,"col":9},"list":{"kind":"ExpressionList","location":{"row":1,"col":9},"elements":[` +
+		`{"kind":"Integer","location":{"row":1,"col":10},"value":1},` +
+		`{"kind":"Integer","location":{"row":1,"col":13},"value":0,"big":2}]}}}}]}`
+
+	if string(out) != expected {
+		t.Errorf("Expected JSON:\n%s\nbut got:\n%s", expected, out)
+	}
+}
+
+func testDictionary() *Dictionary {
+	return &Dictionary{
+		Token: token.Token{Type: token.LBRACK, Lexeme: "[", Location: token.Location{Row: 1, Col: 9}},
+		Pairs: []*DictionaryPair{
+			{
+				Key:   &String{Token: token.Token{Type: token.STRING, Lexeme: "a", Location: token.Location{Row: 1, Col: 10}}, Value: "a"},
+				Value: &Integer{Token: token.Token{Type: token.INTEGER, Lexeme: "1", Location: token.Location{Row: 1, Col: 15}}, Value: 1},
+			},
+		},
+	}
+}
+
+func TestDumpDictionary(t *testing.T) {
+	expected := `Dictionary 1:9
+  pairs[0]: DictionaryPair 1:10
+    key: String 1:10 value="a"
+    value: Integer 1:15 value=1
+`
+
+	if actual := NewDump(testDictionary()).String(); actual != expected {
+		t.Errorf("Expected tree:\n%s\nbut got:\n%s", expected, actual)
+	}
+
+	out, err := json.Marshal(NewDump(testDictionary()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedJSON := `{"kind":"Dictionary","location":{"row":1,"col":9},"pairs":[` +
+		`{"kind":"DictionaryPair","location":{"row":1,"col":10},` +
+		`"key":{"kind":"String","location":{"row":1,"col":10},"value":"a"},` +
+		`"value":{"kind":"Integer","location":{"row":1,"col":15},"value":1}}]}`
+
+	if string(out) != expectedJSON {
+		t.Errorf("Expected JSON:\n%s\nbut got:\n%s", expectedJSON, out)
+	}
+}
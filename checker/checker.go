@@ -0,0 +1,738 @@
+// Package checker finds mistakes in source code
+// without running it: undefined identifiers, assignments
+// to immutables, wrong argument counts, unknown module
+// members and unreachable code.
+package checker
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/interpreter"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/library"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is a problem found in a source file.
+type Diagnostic struct {
+	File     string
+	Location token.Location
+	Message  string
+}
+
+// String formats the diagnostic as file:line:col: message.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Location.Row, d.Location.Col, d.Message)
+}
+
+// Definition is where a name is declared: a LET, VAR,
+// function parameter, loop argument or module.
+type Definition struct {
+	Name     string
+	Kind     string // One of let, var, param, loop or module.
+	Module   string // Set for module members.
+	File     string // Empty for the Standard Library.
+	Location token.Location
+	Function *ast.Function // Set when bound to a function literal.
+}
+
+// Signature describes the definition as declared,
+// like "let add = func (a, b)".
+func (d Definition) Signature() string {
+	name := d.Name
+	if d.Module != "" {
+		name = d.Module + "." + name
+	}
+
+	switch {
+	case d.Kind == "module":
+		return "module " + name
+	case d.Function != nil:
+		params := []string{}
+		for i, param := range d.Function.Parameters {
+			if d.Function.Variadic && i == len(d.Function.Parameters)-1 {
+				params = append(params, "..."+param.Inspect())
+				continue
+			}
+			params = append(params, param.Inspect())
+		}
+		return fmt.Sprintf("%s %s = func (%s)", d.Kind, name, strings.Join(params, ", "))
+	default:
+		return d.Kind + " " + name
+	}
+}
+
+// Reference is a name in a source file, either
+// declared or used, with its definition.
+type Reference struct {
+	File       string
+	Location   token.Location
+	Name       string
+	Definition Definition
+}
+
+// A module with its members.
+type module struct {
+	name     string
+	members  *scope
+	file     string
+	location token.Location
+}
+
+// The definition of a module.
+func (m *module) definition() Definition {
+	return Definition{Name: m.name, Kind: "module", File: m.file, Location: m.location}
+}
+
+// A function literal waiting for its body to be
+// checked, with the scope it closes over.
+type closure struct {
+	function *ast.Function
+	scope    *scope
+	file     string
+}
+
+// Checker analyses source files and the files they
+// import, collecting diagnostics.
+type Checker struct {
+	diagnostics []Diagnostic
+	references  []Reference
+	referred    map[Reference]bool
+	modules     map[string]*module
+	declared    map[*ast.Module]bool
+	imports     map[string]*scope
+	builtins    map[string]bool
+	pending     []closure
+	file        string
+}
+
+// New initializes a Checker that knows about the
+// runtime functions and the Standard Library.
+func New() *Checker {
+	c := &Checker{
+		modules:  map[string]*module{},
+		referred: map[Reference]bool{},
+		declared: map[*ast.Module]bool{},
+		imports:  map[string]*scope{},
+		builtins: map[string]bool{},
+	}
+
+	for _, name := range interpreter.RuntimeFunctions() {
+		c.builtins[name] = true
+	}
+
+	// Library modules are trusted, so only their
+	// members are needed.
+	for _, module := range library.Modules() {
+		if program := c.parse(module.Source); program != nil {
+			c.declareModules(program)
+		}
+	}
+	c.diagnostics = nil
+	c.references = nil
+	c.referred = map[Reference]bool{}
+
+	return c
+}
+
+// CheckFile checks a source file. Files already
+// checked, directly or as imports, are skipped.
+func (c *Checker) CheckFile(path string) {
+	path = filepath.Clean(path)
+	if _, err := c.load(path); err != nil {
+		c.diagnostics = append(c.diagnostics, Diagnostic{File: path, Location: token.Location{Row: 1, Col: 1}, Message: fmt.Sprintf("Couldn't read file '%s'", path)})
+	}
+}
+
+// CheckSource checks source code under the given
+// file name.
+func (c *Checker) CheckSource(name string, source []byte) {
+	c.check(name, source, newScope())
+}
+
+// Diagnostics returns what was found so far, sorted
+// by file and location.
+func (c *Checker) Diagnostics() []Diagnostic {
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i], c.diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Location.Row != b.Location.Row {
+			return a.Location.Row < b.Location.Row
+		}
+		return a.Location.Col < b.Location.Col
+	})
+
+	return c.diagnostics
+}
+
+// References returns the names found in the checked
+// files, in the order they were resolved.
+func (c *Checker) References() []Reference {
+	return c.references
+}
+
+// Modules returns the members of every known module,
+// sorted by name.
+func (c *Checker) Modules() map[string][]Definition {
+	modules := map[string][]Definition{}
+	for name, info := range c.modules {
+		members := []Definition{}
+		for _, member := range info.members.bindings {
+			members = append(members, member.definition())
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].Name < members[j].Name
+		})
+		modules[name] = members
+	}
+
+	return modules
+}
+
+// Check a file once, returning the scope with
+// its top-level names.
+func (c *Checker) load(path string) (*scope, error) {
+	if top, ok := c.imports[path]; ok {
+		return top, nil
+	}
+
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stored before checking, so import
+	// cycles stop here.
+	top := newScope()
+	c.imports[path] = top
+	c.check(path, source, top)
+
+	return top, nil
+}
+
+// Check a file in the given top-level scope.
+func (c *Checker) check(name string, source []byte, top *scope) {
+	// Closures of the importing file wait until
+	// this one is done.
+	file, pending := c.file, c.pending
+	c.file, c.pending = name, nil
+	defer func() {
+		c.file, c.pending = file, pending
+	}()
+
+	program := c.parse(source)
+	if program == nil {
+		return
+	}
+
+	c.declareModules(program)
+	c.statements(program.Statements, top)
+
+	// Function bodies run after the names of their
+	// enclosing scopes are known, so they're checked last.
+	for len(c.pending) > 0 {
+		fn := c.pending[0]
+		c.pending = c.pending[1:]
+		c.file = fn.file
+		c.function(fn.function, fn.scope)
+	}
+}
+
+// Parse source code, turning errors into diagnostics.
+func (c *Checker) parse(source []byte) *ast.Program {
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	lex := lexer.New(reader.New(source))
+	program := parser.New(lex).Parse()
+
+	if reporter.HasErrors() {
+		for _, report := range reporter.GetReports() {
+			c.diagnostics = append(c.diagnostics, Diagnostic{File: c.file, Location: report.Location, Message: report.Message})
+		}
+		return nil
+	}
+
+	return program
+}
+
+// Modules are global, so they're declared before
+// anything else is checked.
+func (c *Checker) declareModules(program *ast.Program) {
+	for _, statement := range program.Statements {
+		if expression, ok := statement.(*ast.ExpressionStatement); ok {
+			if node, ok := expression.Expression.(*ast.Module); ok {
+				c.declareModule(node)
+			}
+		}
+	}
+}
+
+// Declare a module and its members.
+func (c *Checker) declareModule(node *ast.Module) {
+	c.declared[node] = true
+
+	if _, ok := c.modules[node.Name.Value]; ok {
+		c.report(node.Name, fmt.Sprintf("Module '%s' redeclared", node.Name.Value))
+		return
+	}
+
+	info := &module{name: node.Name.Value, members: newScope(), file: c.file, location: node.Name.TokenLocation()}
+	c.refer(node.Name, info.definition())
+
+	for _, statement := range node.Body.Statements {
+		let := moduleMember(statement)
+		if let == nil {
+			continue
+		}
+
+		if _, ok := info.members.lookup(let.Name.Value); ok {
+			c.report(let.Name, fmt.Sprintf("Identifier '%s' already declared", let.Name.Value))
+			continue
+		}
+
+		function, _ := let.Value.(*ast.Function)
+		member := c.binding(let.Name, letBinding, function)
+		member.module = info.name
+		info.members.declare(member)
+		c.refer(let.Name, member.definition())
+	}
+
+	c.modules[node.Name.Value] = info
+}
+
+// The LET statement of a module member, or nil
+// for anything else.
+func moduleMember(statement ast.Statement) *ast.Let {
+	if expression, ok := statement.(*ast.ExpressionStatement); ok {
+		if let, ok := expression.Expression.(*ast.Let); ok {
+			return let
+		}
+	}
+
+	return nil
+}
+
+// Check a list of statements, reporting anything
+// after a flow-breaking statement.
+func (c *Checker) statements(statements []ast.Statement, s *scope) {
+	var breaking ast.Statement
+
+	for _, statement := range statements {
+		if breaking != nil {
+			c.report(statement, fmt.Sprintf("Unreachable code after %s", breaking.TokenLexeme()))
+			breaking = nil
+		}
+
+		c.statement(statement, s)
+
+		switch statement.(type) {
+		case *ast.Return, *ast.Break, *ast.Continue:
+			breaking = statement
+		}
+	}
+}
+
+// Check a statement.
+func (c *Checker) statement(node ast.Statement, s *scope) {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		c.expression(node.Expression, s)
+	case *ast.Return:
+		c.expression(node.Value, s)
+	case *ast.BlockStatement:
+		c.block(node, newScopeFrom(s))
+	}
+}
+
+// Check a block in its own scope.
+func (c *Checker) block(node *ast.BlockStatement, s *scope) {
+	if node != nil {
+		c.statements(node.Statements, s)
+	}
+}
+
+// Check an expression.
+func (c *Checker) expression(node ast.Expression, s *scope) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		c.identifier(node, s)
+	case *ast.Let:
+		c.expression(node.Value, s)
+		function, _ := node.Value.(*ast.Function)
+		c.declare(node.Name, c.binding(node.Name, letBinding, function), s)
+	case *ast.Var:
+		c.expression(node.Value, s)
+		c.declare(node.Name, c.binding(node.Name, varBinding, nil), s)
+	case *ast.Assign:
+		c.expression(node.Right, s)
+		c.assign(node, s)
+	case *ast.Array:
+		c.expressions(node.List, s)
+	case *ast.Set:
+		c.expressions(node.List, s)
+	case *ast.Dictionary:
+		for _, pair := range node.Pairs {
+			c.expression(pair.Key, s)
+			c.expression(pair.Value, s)
+		}
+	case *ast.Subscript:
+		c.expression(node.Left, s)
+		c.expression(node.Index, s)
+	case *ast.Slice:
+		c.expression(node.Start, s)
+		c.expression(node.End, s)
+		c.expression(node.Step, s)
+	case *ast.Pipe:
+		c.expression(node.Left, s)
+		// The left side is passed as the first argument.
+		if call, ok := node.Right.(*ast.FunctionCall); ok {
+			c.call(call, 1, s)
+		} else {
+			c.expression(node.Right, s)
+		}
+	case *ast.If:
+		c.expression(node.Condition, s)
+		c.block(node.Then, newScopeFrom(s))
+		c.block(node.Else, newScopeFrom(s))
+	case *ast.Switch:
+		c.expression(node.Control, s)
+		for _, switchCase := range node.Cases {
+			c.expressions(switchCase.Values, s)
+			c.block(switchCase.Body, newScopeFrom(s))
+		}
+		c.block(node.Default, newScopeFrom(s))
+	case *ast.For:
+		c.expression(node.Enumerable, s)
+		// Loop arguments are written to the enclosing
+		// scope, overwriting what was there.
+		if node.Arguments != nil {
+			for _, argument := range node.Arguments.Elements {
+				b, ok := s.lookup(argument.Value)
+				if !ok {
+					b = c.binding(argument, loopBinding, nil)
+					s.declare(b)
+				}
+				c.refer(argument, b.definition())
+			}
+		}
+		c.block(node.Body, newScopeFrom(s))
+	case *ast.Module:
+		c.module(node)
+	case *ast.ModuleAccess:
+		c.moduleAccess(node)
+	case *ast.Function:
+		c.pending = append(c.pending, closure{function: node, scope: s, file: c.file})
+	case *ast.FunctionCall:
+		c.call(node, 0, s)
+	case *ast.Import:
+		c.importFile(node, s)
+	case *ast.Is:
+		c.expression(node.Left, s)
+	case *ast.As:
+		c.expression(node.Left, s)
+	case *ast.PrefixExpression:
+		c.expression(node.Right, s)
+	case *ast.InfixExpression:
+		c.expression(node.Left, s)
+		c.expression(node.Right, s)
+	}
+}
+
+// Check a list of expressions.
+func (c *Checker) expressions(list *ast.ExpressionList, s *scope) {
+	if list == nil {
+		return
+	}
+
+	for _, element := range list.Elements {
+		c.expression(element, s)
+	}
+}
+
+// Check that an identifier resolves.
+func (c *Checker) identifier(node *ast.Identifier, s *scope) *binding {
+	if b, ok := s.lookup(node.Value); ok {
+		c.refer(node, b.definition())
+		return b
+	}
+
+	message := fmt.Sprintf("Identifier '%s' not found in current scope", node.Value)
+	c.report(node, message+suggest(node.Value, s.names()))
+
+	return nil
+}
+
+// Declare a LET or VAR, which can't shadow a
+// visible name.
+func (c *Checker) declare(name *ast.Identifier, b *binding, s *scope) {
+	if _, ok := s.lookup(name.Value); ok {
+		c.report(name, fmt.Sprintf("Identifier '%s' already declared", name.Value))
+		return
+	}
+
+	s.declare(b)
+	c.refer(name, b.definition())
+}
+
+// Create a binding declared by an identifier
+// in the current file.
+func (c *Checker) binding(name *ast.Identifier, kind bindingKind, function *ast.Function) *binding {
+	return &binding{name: name.Value, kind: kind, function: function, file: c.file, location: name.TokenLocation()}
+}
+
+// Record a name and its definition. Like diagnostics,
+// the same identifier can be reached twice.
+func (c *Checker) refer(name *ast.Identifier, definition Definition) {
+	reference := Reference{File: c.file, Location: name.TokenLocation(), Name: name.Value, Definition: definition}
+	if c.referred[reference] {
+		return
+	}
+
+	c.referred[reference] = true
+	c.references = append(c.references, reference)
+}
+
+// Check the target of an assignment.
+func (c *Checker) assign(node *ast.Assign, s *scope) {
+	var name *ast.Identifier
+
+	switch target := node.Name.(type) {
+	case *ast.Identifier:
+		name = target
+	case *ast.Subscript:
+		c.expression(target.Index, s)
+		name, _ = target.Left.(*ast.Identifier)
+	}
+
+	if name == nil {
+		return
+	}
+
+	if b := c.identifier(name, s); b != nil && b.kind == letBinding {
+		c.report(name, fmt.Sprintf("Identifier '%s' is immutable", name.Value))
+	}
+}
+
+// Check the members of a module in their own scope,
+// which only sees the other members.
+func (c *Checker) module(node *ast.Module) {
+	if !c.declared[node] {
+		c.declareModule(node)
+	}
+
+	info, ok := c.modules[node.Name.Value]
+	if !ok {
+		return
+	}
+
+	for _, statement := range node.Body.Statements {
+		let := moduleMember(statement)
+		if let == nil {
+			c.report(statement, "Only LET statements are accepted as Module members")
+			continue
+		}
+
+		c.expression(let.Value, info.members)
+	}
+}
+
+// Check a module access, returning the member.
+func (c *Checker) moduleAccess(node *ast.ModuleAccess) *binding {
+	info, ok := c.modules[node.Object.Value]
+	if !ok {
+		message := fmt.Sprintf("Module '%s' not found", node.Object.Value)
+		c.report(node.Object, message+suggest(node.Object.Value, c.moduleNames()))
+		return nil
+	}
+	c.refer(node.Object, info.definition())
+
+	member, ok := info.members.bindings[node.Parameter.Value]
+	if !ok {
+		message := fmt.Sprintf("Member '%s' in module '%s' not found", node.Parameter.Value, node.Object.Value)
+		c.report(node.Parameter, message+suggest(node.Parameter.Value, info.members.names()))
+		return nil
+	}
+	c.refer(node.Parameter, member.definition())
+
+	return member
+}
+
+// Names of the declared modules.
+func (c *Checker) moduleNames() []string {
+	names := []string{}
+	for name := range c.modules {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Check a function body in a new scope with
+// its parameters.
+func (c *Checker) function(node *ast.Function, s *scope) {
+	fnscope := newScopeFrom(s)
+
+	for _, param := range node.Parameters {
+		c.expression(param.Default, s)
+		b := c.binding(param.Name, paramBinding, nil)
+		fnscope.declare(b)
+		c.refer(param.Name, b.definition())
+	}
+
+	c.block(node.Body, fnscope)
+}
+
+// Check a function call with any extra arguments
+// passed implicitly, like from a pipe.
+func (c *Checker) call(node *ast.FunctionCall, extra int, s *scope) {
+	var callee *binding
+	var name string
+	var at ast.Node = node.Function
+
+	switch function := node.Function.(type) {
+	case *ast.Identifier:
+		name = function.Value
+		// Runtime functions take precedence
+		// over the scope.
+		if !c.builtins[name] {
+			callee = c.identifier(function, s)
+		}
+	case *ast.ModuleAccess:
+		name = function.Object.Value + "." + function.Parameter.Value
+		callee = c.moduleAccess(function)
+		at = function.Object
+	default:
+		c.expression(node.Function, s)
+	}
+
+	c.expressions(node.Arguments, s)
+
+	if callee == nil || callee.function == nil {
+		return
+	}
+
+	fn := callee.function
+	count := extra
+	if node.Arguments != nil {
+		count += len(node.Arguments.Elements)
+	}
+
+	defaults := 0
+	for _, param := range fn.Parameters {
+		if param.Default != nil {
+			defaults++
+		}
+	}
+
+	switch {
+	case !fn.Variadic && count > len(fn.Parameters):
+		c.report(at, fmt.Sprintf("Too many arguments in call to '%s': expected %d, got %d", name, len(fn.Parameters), count))
+	case count < len(fn.Parameters)-defaults:
+		c.report(at, fmt.Sprintf("Too few arguments in call to '%s': expected %d, got %d", name, len(fn.Parameters)-defaults, count))
+	}
+}
+
+// Check an imported file, bringing its top-level
+// names into the importing scope.
+func (c *Checker) importFile(node *ast.Import, s *scope) {
+	path := node.File.Value
+	if filepath.Ext(path) == "" {
+		path += ".ari"
+	}
+
+	top, err := c.load(filepath.Clean(path))
+	if err != nil {
+		c.report(node, fmt.Sprintf("Couldn't read imported file '%s'", node.File.Value))
+		return
+	}
+
+	for name, b := range top.bindings {
+		s.bindings[name] = b
+	}
+}
+
+// Add a diagnostic at the location of a node. The same
+// identifier can be reached twice, like in shorthand
+// assignments, so duplicates are dropped.
+func (c *Checker) report(node ast.Node, message string) {
+	diagnostic := Diagnostic{File: c.file, Location: node.TokenLocation(), Message: message}
+	for _, existing := range c.diagnostics {
+		if existing == diagnostic {
+			return
+		}
+	}
+
+	c.diagnostics = append(c.diagnostics, diagnostic)
+}
+
+// A "did you mean" hint with the closest of the
+// candidates, if any is close enough to be a typo.
+func suggest(name string, candidates []string) string {
+	best := ""
+	bestDistance := len(name)/3 + 1
+
+	sort.Strings(candidates)
+	for _, candidate := range candidates {
+		if distance := editDistance(name, candidate); distance > 0 && distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", did you mean '%s'?", best)
+}
+
+// Edit distance between two strings, where swapping
+// two adjacent characters counts as one edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	distances := make([][]int, len(ra)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(rb)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			distances[i][j] = min(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				distances[i][j] = min(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return distances[len(ra)][len(rb)]
+}
+
+// Smallest of some integers.
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
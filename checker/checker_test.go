@@ -0,0 +1,165 @@
+package checker
+
+import (
+	"fmt"
+	"github.com/fadion/aria/library"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCheckSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let a = 1\na + 1", []string{}},
+		{"let a = 1\na + b", []string{"test.ari:2:5: Identifier 'b' not found in current scope"}},
+		{"let count = 1\ncoutn + 1", []string{"test.ari:2:1: Identifier 'coutn' not found in current scope, did you mean 'count'?"}},
+		{"let a = 1\na = 2", []string{"test.ari:2:1: Identifier 'a' is immutable"}},
+		{"var a = 1\na += 2", []string{}},
+		{"let a = 1\nlet a = 2", []string{"test.ari:2:5: Identifier 'a' already declared"}},
+		{"let add = func (x, y) do x + y end\nadd(1)", []string{"test.ari:2:1: Too few arguments in call to 'add': expected 2, got 1"}},
+		{"let add = func (x, y) do x + y end\nadd(1, 2, 3)", []string{"test.ari:2:1: Too many arguments in call to 'add': expected 2, got 3"}},
+		{"let add = func (x, y = 1) do x + y end\nadd(1)\n1 |> add()", []string{}},
+		{"let sum = func (...nums) do nums end\nsum(1, 2, 3)", []string{}},
+		{"Enum.size([1])\nEnum.szie([1])", []string{"test.ari:2:6: Member 'szie' in module 'Enum' not found, did you mean 'size'?"}},
+		{"Enmu.size([1])", []string{"test.ari:1:1: Module 'Enmu' not found, did you mean 'Enum'?"}},
+		{"Enum.size()", []string{"test.ari:1:1: Too few arguments in call to 'Enum.size': expected 1, got 0"}},
+		{"let f = func ()\n  return 1\n  2\nend", []string{"test.ari:3:3: Unreachable code after return"}},
+		{"for i in 1..3\n  break\n  i\nend", []string{"test.ari:3:3: Unreachable code after break"}},
+		{"println(1)\nruntime_rand(1, 2)", []string{}},
+	}
+
+	for _, test := range tests {
+		c := New()
+		c.CheckSource("test.ari", []byte(test.input))
+		checkDiagnostics(t, test.input, c.Diagnostics(), test.expected)
+	}
+}
+
+// The Standard Library itself checks cleanly, including
+// members bound to native functions. The module is left
+// out of those declared up front, so it's checked as any
+// other source.
+func TestCheckLibrary(t *testing.T) {
+	for _, module := range library.Modules() {
+		c := New()
+		delete(c.modules, module.Name)
+		c.CheckSource(module.File, module.Source)
+		checkDiagnostics(t, module.File, c.Diagnostics(), []string{})
+	}
+}
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Function bodies see names declared after them.
+		{"let even? = func (n) do n == 0 ? true : odd?(n - 1) end\nlet odd? = func (n) do n == 0 ? false : even?(n - 1) end", []string{}},
+		{"let f = func (x) do x end\nx", []string{"test.ari:2:1: Identifier 'x' not found in current scope"}},
+		{"if true\n  let a = 1\nend\na", []string{"test.ari:4:1: Identifier 'a' not found in current scope"}},
+		// Loop arguments leak into the enclosing scope.
+		{"for v in [1, 2]\n  v\nend\nv", []string{}},
+		// Modules only see their own members.
+		{"let a = 1\nmodule M\n  let b = func () do c() + a end\n  let c = func () do 1 end\nend\nM.b()", []string{"test.ari:3:28: Identifier 'a' not found in current scope"}},
+		{"module M\n  var a = 1\nend", []string{"test.ari:2:3: Only LET statements are accepted as Module members"}},
+	}
+
+	for _, test := range tests {
+		c := New()
+		c.CheckSource("test.ari", []byte(test.input))
+		checkDiagnostics(t, test.input, c.Diagnostics(), test.expected)
+	}
+}
+
+func TestCheckParseErrors(t *testing.T) {
+	c := New()
+	c.CheckSource("test.ari", []byte("let = 1"))
+
+	if len(c.Diagnostics()) == 0 {
+		t.Errorf("Expected parse errors as diagnostics")
+	}
+
+	for _, diagnostic := range c.Diagnostics() {
+		if diagnostic.File != "test.ari" || diagnostic.Location.Row != 1 {
+			t.Errorf("Expected the error in test.ari at line 1 but got %s", diagnostic)
+		}
+	}
+}
+
+func TestCheckImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(dir)
+
+	ioutil.WriteFile("lib.ari", []byte("let double = func (x) do x * 2 end\nmissing"), 0644)
+	ioutil.WriteFile("main.ari", []byte("import \"lib\"\ndouble(1, 2)\nimport \"nope\""), 0644)
+
+	c := New()
+	c.CheckFile("main.ari")
+	c.CheckFile("lib.ari")
+
+	checkDiagnostics(t, "main.ari", c.Diagnostics(), []string{
+		"lib.ari:2:1: Identifier 'missing' not found in current scope",
+		"main.ari:2:1: Too many arguments in call to 'double': expected 1, got 2",
+		"main.ari:3:1: Couldn't read imported file 'nope'",
+	})
+}
+
+func TestCheckReferences(t *testing.T) {
+	input := "module M\n  let f = func (x) do x end\nend\nvar total = M.f(1)\nfor i in [1] do total += i end"
+
+	c := New()
+	c.CheckSource("test.ari", []byte(input))
+
+	expected := []string{
+		"1:8 M -> module M at 1:8",
+		"2:7 f -> let M.f = func (x) at 2:7",
+		"4:13 M -> module M at 1:8",
+		"4:15 f -> let M.f = func (x) at 2:7",
+		"4:5 total -> var total at 4:5",
+		"5:5 i -> loop i at 5:5",
+		"5:17 total -> var total at 4:5",
+		"5:26 i -> loop i at 5:5",
+		"2:17 x -> param x at 2:17",
+		"2:23 x -> param x at 2:17",
+	}
+
+	references := c.References()
+	if len(references) != len(expected) {
+		t.Fatalf("Expected %d references but got %d: %v", len(expected), len(references), references)
+	}
+
+	for i, reference := range references {
+		definition := reference.Definition
+		actual := fmt.Sprintf("%d:%d %s -> %s at %d:%d", reference.Location.Row, reference.Location.Col, reference.Name, definition.Signature(), definition.Location.Row, definition.Location.Col)
+		if actual != expected[i] {
+			t.Errorf("Expected %q but got %q", expected[i], actual)
+		}
+	}
+
+	if members := c.Modules()["M"]; len(members) != 1 || members[0].Name != "f" {
+		t.Errorf("Expected the members of M but got %v", members)
+	}
+}
+
+func checkDiagnostics(t *testing.T, input string, actual []Diagnostic, expected []string) {
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %d diagnostics for %q but got %d: %v", len(expected), input, len(actual), actual)
+		return
+	}
+
+	for i, diagnostic := range actual {
+		if diagnostic.String() != expected[i] {
+			t.Errorf("Expected %q but got %q", expected[i], diagnostic.String())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/token"
+)
+
+// Kind of a name binding.
+type bindingKind int
+
+const (
+	letBinding bindingKind = iota
+	varBinding
+	paramBinding
+	loopBinding
+)
+
+// Names of the binding kinds, as shown in definitions.
+var kindNames = map[bindingKind]string{
+	letBinding:   "let",
+	varBinding:   "var",
+	paramBinding: "param",
+	loopBinding:  "loop",
+}
+
+// A name as it's known statically.
+type binding struct {
+	name     string
+	kind     bindingKind
+	function *ast.Function // Set when a LET is bound to a function literal.
+	module   string        // Set for module members.
+	file     string
+	location token.Location
+}
+
+// The definition of a binding.
+func (b *binding) definition() Definition {
+	return Definition{
+		Name:     b.name,
+		Kind:     kindNames[b.kind],
+		Module:   b.module,
+		File:     b.file,
+		Location: b.location,
+		Function: b.function,
+	}
+}
+
+// Static counterpart of the interpreter's scope,
+// holding bindings instead of values.
+type scope struct {
+	bindings map[string]*binding
+	parent   *scope
+}
+
+// Create a scope without a parent.
+func newScope() *scope {
+	return &scope{bindings: map[string]*binding{}}
+}
+
+// Create a scope inside another.
+func newScopeFrom(parent *scope) *scope {
+	s := newScope()
+	s.parent = parent
+
+	return s
+}
+
+// Find a binding in the scope and its parents.
+func (s *scope) lookup(name string) (*binding, bool) {
+	for current := s; current != nil; current = current.parent {
+		if b, ok := current.bindings[name]; ok {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+// Add a binding to the scope.
+func (s *scope) declare(b *binding) {
+	s.bindings[b.name] = b
+}
+
+// Names visible from the scope.
+func (s *scope) names() []string {
+	names := []string{}
+	for current := s; current != nil; current = current.parent {
+		for name := range current.bindings {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
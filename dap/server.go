@@ -0,0 +1,553 @@
+// Package dap implements the Debug Adapter Protocol over
+// stdio, letting editors debug Aria programs with
+// breakpoints, stepping and variable panes.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/debugger"
+	"github.com/fadion/aria/interpreter"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/wire"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Programs run in a single thread.
+const threadID = 1
+
+// Server debugs a program for an editor. The program runs
+// in its own goroutine, blocking in the interpreter's hook
+// while paused, so requests keep being answered.
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// Guards writing messages and everything shared
+	// with the program's goroutine.
+	mutex       sync.Mutex
+	seq         int
+	control     *debugger.Control
+	runner      *interpreter.Interpreter
+	program     *ast.Program
+	paths       map[string]string
+	stopOnEntry bool
+	launched    bool
+	configured  bool
+	started     bool
+	paused      bool
+	stopping    bool
+	frames      []interpreter.Frame
+	references  []interface{}
+	resume      chan bool
+	done        chan struct{}
+}
+
+// NewServer initializes a Server reading requests from
+// in and writing responses and events to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		in:      bufio.NewReader(in),
+		out:     out,
+		control: debugger.NewControl(),
+		runner:  interpreter.New(),
+		paths:   map[string]string{},
+		resume:  make(chan bool),
+		done:    make(chan struct{}),
+	}
+
+	s.runner.SetHook(s.hook)
+	s.runner.SetOutput(outputWriter{s})
+
+	return s
+}
+
+// Run answers requests until the client disconnects.
+func (s *Server) Run() error {
+	for {
+		body, err := wire.Read(s.in)
+		if err == io.EOF {
+			s.stop()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			continue
+		}
+
+		result, err := s.handle(req)
+		if err != nil {
+			s.send(response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Message: err.Error()})
+			continue
+		}
+		s.send(response{Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: result})
+
+		// Whatever follows the response.
+		switch req.Command {
+		case "initialize":
+			s.sendEvent("initialized", nil)
+		case "launch", "configurationDone":
+			s.start()
+		case "continue", "next", "stepIn", "stepOut":
+			s.resume <- true
+		case "disconnect":
+			s.stop()
+			return nil
+		}
+	}
+}
+
+// Dispatch a request to its handler.
+func (s *Server) handle(req request) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return capabilities{SupportsConfigurationDoneRequest: true, SupportsEvaluateForHovers: true}, nil
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+	case "configurationDone":
+		s.mutex.Lock()
+		s.configured = true
+		s.mutex.Unlock()
+		return nil, nil
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(args), nil
+	case "setExceptionBreakpoints":
+		return nil, nil
+	case "threads":
+		return threadsBody{Threads: []thread{{ID: threadID, Name: "main"}}}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		var args scopesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+	case "variables":
+		var args variablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "evaluate":
+		var args evaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.evaluate(args)
+	case "continue":
+		return continueBody{AllThreadsContinued: true}, s.step(debugger.Continue)
+	case "next":
+		return nil, s.step(debugger.StepOver)
+	case "stepIn":
+		return nil, s.step(debugger.StepIn)
+	case "stepOut":
+		return nil, s.step(debugger.StepOut)
+	case "disconnect", "terminate":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("Unknown command '%s'", req.Command)
+}
+
+// Load the program to debug. It starts once the
+// client is done with the configuration.
+func (s *Server) launch(args launchArguments) error {
+	file, err := filepath.Abs(args.Program)
+	if err != nil {
+		return err
+	}
+
+	source, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("Couldn't read '%s'", args.Program)
+	}
+
+	reporter.ClearErrors()
+	program := parser.New(lexer.New(reader.New(source))).Parse()
+	if reporter.HasErrors() {
+		message := strings.Join(reporter.GetErrors(), "\n")
+		reporter.ClearErrors()
+		return errors.New(message)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.program = program
+	s.launched = true
+	s.stopOnEntry = args.StopOnEntry
+	s.runner.SetFile(file)
+	if !args.StopOnEntry {
+		s.control.Resume(debugger.Continue, 0)
+	}
+
+	return nil
+}
+
+// Start the program when it's launched and configured.
+func (s *Server) start() {
+	s.mutex.Lock()
+	ready := s.launched && s.configured && !s.started
+	if ready {
+		s.started = true
+	}
+	s.mutex.Unlock()
+
+	if ready {
+		go s.execute()
+	}
+}
+
+// Run the program, reporting how it ended.
+func (s *Server) execute() {
+	defer close(s.done)
+
+	s.runner.Interpret(s.program, interpreter.NewScope())
+
+	code := 0
+	if reporter.HasErrors() {
+		code = 1
+		for _, err := range reporter.GetErrors() {
+			s.sendEvent("output", outputBody{Category: "stderr", Output: err + "\n"})
+		}
+		reporter.ClearErrors()
+	}
+
+	s.sendEvent("exited", exitedBody{ExitCode: code})
+	s.sendEvent("terminated", nil)
+}
+
+// Stop the program, waking it up if it's paused,
+// and wait for it to end.
+func (s *Server) stop() {
+	s.mutex.Lock()
+	s.stopping = true
+	started, paused := s.started, s.paused
+	s.paused = false
+	s.mutex.Unlock()
+
+	if paused {
+		s.resume <- false
+	}
+
+	if started {
+		<-s.done
+	}
+}
+
+// Called by the interpreter before every statement,
+// in the program's goroutine. Pausing blocks it until
+// the client resumes.
+func (s *Server) hook(node ast.Statement, scope *interpreter.Scope) bool {
+	frames := s.runner.Frames()
+	frame := frames[len(frames)-1]
+
+	s.mutex.Lock()
+	if s.stopping {
+		s.mutex.Unlock()
+		return false
+	}
+
+	at := debugger.Breakpoint{File: s.absolute(frame.File), Line: frame.Location.Row}
+	if !s.control.ShouldPause(at, len(frames)) {
+		s.mutex.Unlock()
+		return true
+	}
+
+	reason := "step"
+	switch {
+	case s.stopOnEntry:
+		reason = "entry"
+		s.stopOnEntry = false
+	case s.control.HasBreakpoint(at):
+		reason = "breakpoint"
+	}
+
+	s.paused = true
+	s.frames = frames
+	s.references = nil
+	s.mutex.Unlock()
+
+	s.sendEvent("stopped", stoppedBody{Reason: reason, ThreadID: threadID, AllThreadsStopped: true})
+
+	return <-s.resume
+}
+
+// Replace the breakpoints of a file.
+func (s *Server) setBreakpoints(args setBreakpointsArguments) setBreakpointsBody {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file := s.absolute(args.Source.Path)
+	s.control.ClearFile(file)
+
+	body := setBreakpointsBody{Breakpoints: []breakpoint{}}
+	for _, at := range args.Breakpoints {
+		s.control.SetBreakpoint(debugger.Breakpoint{File: file, Line: at.Line})
+		body.Breakpoints = append(body.Breakpoints, breakpoint{Verified: true, Line: at.Line})
+	}
+
+	return body
+}
+
+// Resume the paused program in a mode. The program is
+// woken up after the response is sent.
+func (s *Server) step(mode debugger.Mode) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.paused {
+		return errors.New("Program isn't paused")
+	}
+
+	s.control.Resume(mode, len(s.frames))
+	s.paused = false
+
+	return nil
+}
+
+// The call stack of the paused program, innermost
+// call first. Frame IDs are their depth.
+func (s *Server) stackTrace() (interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.paused {
+		return nil, errors.New("Program isn't paused")
+	}
+
+	body := stackTraceBody{StackFrames: []stackFrame{}, TotalFrames: len(s.frames)}
+	for index := len(s.frames) - 1; index >= 0; index-- {
+		frame := s.frames[index]
+
+		src := source{Name: frame.File}
+		if frame.File != interpreter.LibraryFile {
+			src = source{Name: filepath.Base(frame.File), Path: s.absolute(frame.File)}
+		}
+
+		body.StackFrames = append(body.StackFrames, stackFrame{
+			ID:     index + 1,
+			Name:   frame.Name,
+			Source: src,
+			Line:   frame.Location.Row,
+			Column: frame.Location.Col,
+		})
+	}
+
+	return body, nil
+}
+
+// The scope chain of a frame: its locals, the scopes
+// of enclosing functions and the globals.
+func (s *Server) scopes(id int) (interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	frame, err := s.frame(id)
+	if err != nil {
+		return nil, err
+	}
+
+	body := scopesBody{Scopes: []scope{}}
+	for current := frame.Scope; current != nil; current = current.Parent() {
+		name := "Closure"
+		switch {
+		case current.Parent() == nil:
+			name = "Globals"
+		case current == frame.Scope:
+			name = "Locals"
+		}
+
+		if name == "Closure" && len(current.Locals()) == 0 {
+			continue
+		}
+
+		body.Scopes = append(body.Scopes, scope{Name: name, VariablesReference: s.reference(current)})
+	}
+
+	return body, nil
+}
+
+// The variables of a scope, or the elements of an
+// array or dictionary.
+func (s *Server) variables(reference int) (interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if reference < 1 || reference > len(s.references) {
+		return nil, fmt.Errorf("Unknown variables reference %d", reference)
+	}
+
+	body := variablesBody{Variables: []variable{}}
+	switch value := s.references[reference-1].(type) {
+	case *interpreter.Scope:
+		for _, name := range value.Locals() {
+			element, _ := value.Read(name)
+			body.Variables = append(body.Variables, s.variable(name, element))
+		}
+	case *interpreter.ArrayType:
+		for index, element := range value.Elements {
+			body.Variables = append(body.Variables, s.variable(strconv.Itoa(index), element))
+		}
+	case *interpreter.DictionaryType:
+		for _, pair := range value.Pairs {
+			body.Variables = append(body.Variables, s.variable(pair.Key.Inspect(), pair.Value))
+		}
+	}
+
+	return body, nil
+}
+
+// Evaluate an expression in a child of a frame's
+// scope, so declarations don't leak.
+func (s *Server) evaluate(args evaluateArguments) (interface{}, error) {
+	s.mutex.Lock()
+	frame, err := s.frame(args.FrameID)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	program := parser.New(lexer.New(reader.New([]byte(args.Expression)))).Parse()
+	if reporter.HasErrors() {
+		return nil, errors.New(strings.Join(reporter.GetErrors(), "\n"))
+	}
+
+	// Printing during the evaluation sends events,
+	// so the lock isn't held while interpreting.
+	result := s.runner.Interpret(program, interpreter.NewScopeFrom(frame.Scope))
+	if reporter.HasErrors() {
+		return nil, errors.New(strings.Join(reporter.GetErrors(), "\n"))
+	}
+	if result == nil {
+		return evaluateBody{Result: "nil", Type: interpreter.NIL_TYPE}, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return evaluateBody{Result: result.Inspect(), Type: result.Type(), VariablesReference: s.expandable(result)}, nil
+}
+
+// A frame of the paused program by ID, or the
+// innermost one for 0.
+func (s *Server) frame(id int) (interpreter.Frame, error) {
+	if !s.paused {
+		return interpreter.Frame{}, errors.New("Program isn't paused")
+	}
+
+	if id == 0 {
+		id = len(s.frames)
+	}
+
+	if id < 1 || id > len(s.frames) {
+		return interpreter.Frame{}, fmt.Errorf("Unknown frame %d", id)
+	}
+
+	return s.frames[id-1], nil
+}
+
+// A variable, expandable when it holds elements.
+func (s *Server) variable(name string, value interpreter.DataType) variable {
+	return variable{Name: name, Value: value.Inspect(), Type: value.Type(), VariablesReference: s.expandable(value)}
+}
+
+// Reference of arrays and dictionaries with elements,
+// or 0 for anything else.
+func (s *Server) expandable(value interpreter.DataType) int {
+	switch value := value.(type) {
+	case *interpreter.ArrayType:
+		if len(value.Elements) > 0 {
+			return s.reference(value)
+		}
+	case *interpreter.DictionaryType:
+		if len(value.Pairs) > 0 {
+			return s.reference(value)
+		}
+	}
+
+	return 0
+}
+
+// Store something the client can ask variables of.
+// References last until the program resumes.
+func (s *Server) reference(value interface{}) int {
+	s.references = append(s.references, value)
+	return len(s.references)
+}
+
+// Absolute path of a file, remembered because it's
+// needed for every statement.
+func (s *Server) absolute(file string) string {
+	if path, ok := s.paths[file]; ok {
+		return path
+	}
+
+	path := file
+	if file != interpreter.LibraryFile {
+		if absolute, err := filepath.Abs(file); err == nil {
+			path = absolute
+		}
+	}
+	s.paths[file] = path
+
+	return path
+}
+
+// Send an event.
+func (s *Server) sendEvent(name string, body interface{}) {
+	s.send(event{Type: "event", Event: name, Body: body})
+}
+
+// Send a message, numbering it.
+func (s *Server) send(message interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seq++
+	switch message := message.(type) {
+	case response:
+		message.Seq = s.seq
+		wire.Write(s.out, message)
+	case event:
+		message.Seq = s.seq
+		wire.Write(s.out, message)
+	}
+}
+
+// Sends what the program prints as output events.
+type outputWriter struct {
+	server *Server
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.server.sendEvent("output", outputBody{Category: "stdout", Output: string(p)})
+	return len(p), nil
+}
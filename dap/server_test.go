@@ -0,0 +1,259 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/fadion/aria/wire"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSource = `let double = func (x)
+  let y = x * 2
+  y
+end
+var list = [1, 2]
+var total = double(2)
+println(total)
+total = double(total)`
+
+// A scripted client talking to a server.
+type client struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan map[string]interface{}
+	seq      int
+	events   []map[string]interface{}
+}
+
+// Start a server with a client connected to it. Messages
+// are read as soon as they're written, like stdio would
+// buffer them.
+func newClient(t *testing.T) *client {
+	requests, requestsWriter := io.Pipe()
+	responses, responsesWriter := io.Pipe()
+
+	go func() {
+		NewServer(requests, responsesWriter).Run()
+		responsesWriter.Close()
+	}()
+
+	messages := make(chan map[string]interface{}, 100)
+	go func() {
+		out := bufio.NewReader(responses)
+		for {
+			body, err := wire.Read(out)
+			if err != nil {
+				close(messages)
+				return
+			}
+
+			var message map[string]interface{}
+			if json.Unmarshal(body, &message) == nil {
+				messages <- message
+			}
+		}
+	}()
+
+	return &client{t: t, in: requestsWriter, messages: messages}
+}
+
+// Send a request and wait for its response, keeping
+// the events that come before it.
+func (c *client) request(command string, arguments interface{}) map[string]interface{} {
+	c.seq++
+	if err := wire.Write(c.in, map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments}); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		message := c.read()
+		if message["type"] == "response" && message["request_seq"] == float64(c.seq) {
+			return message
+		}
+		c.events = append(c.events, message)
+	}
+}
+
+// Wait for an event, returning its body.
+func (c *client) event(name string) map[string]interface{} {
+	for index, message := range c.events {
+		if message["event"] == name {
+			c.events = append(c.events[:index], c.events[index+1:]...)
+			body, _ := message["body"].(map[string]interface{})
+			return body
+		}
+	}
+
+	for {
+		message := c.read()
+		if message["event"] == name {
+			body, _ := message["body"].(map[string]interface{})
+			return body
+		}
+		c.events = append(c.events, message)
+	}
+}
+
+func (c *client) read() map[string]interface{} {
+	message, ok := <-c.messages
+	if !ok {
+		c.t.Fatal("The server closed the connection")
+	}
+
+	return message
+}
+
+// Write the test source to a temporary file.
+func writeSource(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "test.ari")
+	if err := ioutil.WriteFile(file, []byte(testSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return file, func() { os.RemoveAll(dir) }
+}
+
+// Summary of the stack frames in a response.
+func stackSummary(response map[string]interface{}) string {
+	frames := []string{}
+	for _, frame := range response["body"].(map[string]interface{})["stackFrames"].([]interface{}) {
+		frame := frame.(map[string]interface{})
+		frames = append(frames, fmt.Sprintf("%s:%v", frame["name"], frame["line"]))
+	}
+
+	return strings.Join(frames, " ")
+}
+
+// Summary of the variables in a response.
+func variablesSummary(response map[string]interface{}) string {
+	variables := []string{}
+	for _, variable := range response["body"].(map[string]interface{})["variables"].([]interface{}) {
+		variable := variable.(map[string]interface{})
+		variables = append(variables, fmt.Sprintf("%s=%s", variable["name"], variable["value"]))
+	}
+
+	return strings.Join(variables, " ")
+}
+
+func TestDebugSession(t *testing.T) {
+	file, cleanup := writeSource(t)
+	defer cleanup()
+
+	c := newClient(t)
+
+	if response := c.request("initialize", map[string]interface{}{"adapterID": "aria"}); response["success"] != true {
+		t.Fatalf("Expected initialize to succeed but got %v", response)
+	}
+	c.event("initialized")
+
+	c.request("launch", map[string]interface{}{"program": file})
+	breakpoints := c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": file},
+		"breakpoints": []map[string]interface{}{{"line": 2}},
+	})
+	if !strings.Contains(fmt.Sprint(breakpoints["body"]), "verified:true") {
+		t.Errorf("Expected a verified breakpoint but got %v", breakpoints["body"])
+	}
+	c.request("configurationDone", nil)
+
+	if stopped := c.event("stopped"); stopped["reason"] != "breakpoint" {
+		t.Errorf("Expected to stop on a breakpoint but got %v", stopped)
+	}
+
+	if stack := stackSummary(c.request("stackTrace", map[string]interface{}{"threadId": 1})); stack != "double:2 main:6" {
+		t.Errorf("Expected the call stack but got %q", stack)
+	}
+
+	scopes := c.request("scopes", map[string]interface{}{"frameId": 2})["body"].(map[string]interface{})["scopes"].([]interface{})
+	globals := scopes[len(scopes)-1].(map[string]interface{})
+	if globals["name"] != "Globals" {
+		t.Errorf("Expected the globals last but got %v", scopes)
+	}
+
+	if variables := variablesSummary(c.request("variables", map[string]interface{}{"variablesReference": globals["variablesReference"]})); !strings.Contains(variables, "list=[1, 2]") {
+		t.Errorf("Expected list in the globals but got %q", variables)
+	}
+
+	evaluated := c.request("evaluate", map[string]interface{}{"expression": "x + 40", "frameId": 2})
+	if fmt.Sprint(evaluated["body"].(map[string]interface{})["result"]) != "42" {
+		t.Errorf("Expected 42 but got %v", evaluated)
+	}
+
+	c.request("next", map[string]interface{}{"threadId": 1})
+	c.event("stopped")
+	if stack := stackSummary(c.request("stackTrace", map[string]interface{}{"threadId": 1})); stack != "double:3 main:6" {
+		t.Errorf("Expected to step to line 3 but got %q", stack)
+	}
+
+	c.request("stepOut", map[string]interface{}{"threadId": 1})
+	c.event("stopped")
+	if stack := stackSummary(c.request("stackTrace", map[string]interface{}{"threadId": 1})); stack != "main:7" {
+		t.Errorf("Expected to step out to line 7 but got %q", stack)
+	}
+
+	c.request("next", map[string]interface{}{"threadId": 1})
+	if output := c.event("output"); output["output"] != "4\n" {
+		t.Errorf("Expected the program output but got %v", output)
+	}
+	c.event("stopped")
+
+	if response := c.request("continue", map[string]interface{}{"threadId": 1}); response["success"] != true {
+		t.Errorf("Expected continue to succeed but got %v", response)
+	}
+	c.event("stopped")
+	c.request("continue", map[string]interface{}{"threadId": 1})
+
+	if exited := c.event("exited"); exited["exitCode"] != float64(0) {
+		t.Errorf("Expected exit code 0 but got %v", exited)
+	}
+	c.event("terminated")
+
+	c.request("disconnect", nil)
+}
+
+func TestDebugStopOnEntry(t *testing.T) {
+	file, cleanup := writeSource(t)
+	defer cleanup()
+
+	c := newClient(t)
+	c.request("initialize", nil)
+	c.request("launch", map[string]interface{}{"program": file, "stopOnEntry": true})
+	c.request("configurationDone", nil)
+
+	if stopped := c.event("stopped"); stopped["reason"] != "entry" {
+		t.Errorf("Expected to stop on entry but got %v", stopped)
+	}
+
+	if response := c.request("nope", nil); response["success"] != false {
+		t.Errorf("Expected an unknown command to fail but got %v", response)
+	}
+
+	c.request("disconnect", nil)
+}
+
+func TestDebugLaunchErrors(t *testing.T) {
+	c := newClient(t)
+	c.request("initialize", nil)
+
+	response := c.request("launch", map[string]interface{}{"program": "/nope/missing.ari"})
+	if response["success"] != false || !strings.Contains(fmt.Sprint(response["message"]), "Couldn't read") {
+		t.Errorf("Expected launch to fail but got %v", response)
+	}
+
+	if response := c.request("continue", nil); response["success"] != false {
+		t.Errorf("Expected continue to fail without a paused program but got %v", response)
+	}
+
+	c.request("disconnect", nil)
+}
@@ -0,0 +1,105 @@
+package debugger
+
+import "sort"
+
+// Mode is how execution goes on after a pause.
+type Mode int
+
+const (
+	Continue Mode = iota
+	StepIn
+	StepOver
+	StepOut
+)
+
+// Breakpoint is a line of a file to pause at.
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+// Control decides where a program pauses, from its
+// breakpoints and the last way it was resumed. It's
+// shared by the terminal debugger and the adapter
+// for editors.
+type Control struct {
+	breakpoints map[Breakpoint]bool
+	mode        Mode
+	depth       int
+}
+
+// NewControl initializes a Control that pauses
+// at the first statement.
+func NewControl() *Control {
+	return &Control{breakpoints: map[Breakpoint]bool{}, mode: StepIn}
+}
+
+// SetBreakpoint adds a breakpoint.
+func (c *Control) SetBreakpoint(at Breakpoint) {
+	c.breakpoints[at] = true
+}
+
+// ClearBreakpoint removes a breakpoint, reporting
+// whether it existed.
+func (c *Control) ClearBreakpoint(at Breakpoint) bool {
+	if !c.breakpoints[at] {
+		return false
+	}
+
+	delete(c.breakpoints, at)
+	return true
+}
+
+// ClearFile removes the breakpoints of a file.
+func (c *Control) ClearFile(file string) {
+	for at := range c.breakpoints {
+		if at.File == file {
+			delete(c.breakpoints, at)
+		}
+	}
+}
+
+// HasBreakpoint checks for a breakpoint.
+func (c *Control) HasBreakpoint(at Breakpoint) bool {
+	return c.breakpoints[at]
+}
+
+// Breakpoints returns the breakpoints sorted by
+// file and line.
+func (c *Control) Breakpoints() []Breakpoint {
+	sorted := []Breakpoint{}
+	for at := range c.breakpoints {
+		sorted = append(sorted, at)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	return sorted
+}
+
+// Resume goes on in a mode from a call depth, which
+// is the number of frames at the pause.
+func (c *Control) Resume(mode Mode, depth int) {
+	c.mode = mode
+	c.depth = depth
+}
+
+// ShouldPause checks if a statement at a location and
+// call depth pauses the program.
+func (c *Control) ShouldPause(at Breakpoint, depth int) bool {
+	switch c.mode {
+	case StepIn:
+		return true
+	case StepOver:
+		return depth <= c.depth || c.breakpoints[at]
+	case StepOut:
+		return depth < c.depth || c.breakpoints[at]
+	}
+
+	return c.breakpoints[at]
+}
@@ -0,0 +1,339 @@
+// Package debugger runs a source file statement by
+// statement, pausing on breakpoints and steps to inspect
+// the call stack and scopes of the program.
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/interpreter"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fatih/color"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Lines shown around the current one by list.
+const listContext = 5
+
+// Help printed by the help command.
+const help = `Commands:
+  c, continue       Run until the next breakpoint
+  s, step           Run the next statement, stepping into calls
+  n, next           Run the next statement, stepping over calls
+  o, out            Run until the current function returns
+  b, break [LINE]   Set a breakpoint at LINE or FILE:LINE, or list them
+  d, delete LINE    Delete a breakpoint
+  bt, stack         Show the call stack
+  scope             Show the variables of the scope chain
+  p, print EXPR     Evaluate an expression in the current scope
+  l, list           Show the code around the current line
+  q, quit           Stop the program
+An empty line repeats the previous command.`
+
+// Debugger runs a program under the control of
+// commands read from its input.
+type Debugger struct {
+	in      *bufio.Reader
+	out     io.Writer
+	file    string
+	sources map[string][]string
+	control *Control
+	last    string
+	stopped bool
+	runner  *interpreter.Interpreter
+}
+
+// New initializes a Debugger for a file, reading
+// commands from in and writing to out.
+func New(file string, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		in:      bufio.NewReader(in),
+		out:     out,
+		file:    file,
+		sources: map[string][]string{},
+		control: NewControl(),
+		runner:  interpreter.New(),
+	}
+}
+
+// Break sets a breakpoint at a line of the file.
+func (d *Debugger) Break(line int) {
+	d.control.SetBreakpoint(Breakpoint{File: d.file, Line: line})
+}
+
+// Run debugs the source code, pausing before
+// its first statement.
+func (d *Debugger) Run(source []byte) {
+	d.sources[d.file] = strings.Split(string(source), "\n")
+
+	reporter.ClearErrors()
+	program := parser.New(lexer.New(reader.New(source))).Parse()
+	if d.printErrors() {
+		return
+	}
+
+	d.runner.SetFile(d.file)
+	d.runner.SetOutput(d.out)
+	d.runner.SetHook(d.hook)
+	d.runner.Interpret(program, interpreter.NewScope())
+
+	switch {
+	case d.stopped:
+		fmt.Fprintln(d.out, "Program stopped")
+	case !d.printErrors():
+		fmt.Fprintln(d.out, "Program finished")
+	}
+}
+
+// Decide whether to pause before a statement.
+func (d *Debugger) hook(node ast.Statement, scope *interpreter.Scope) bool {
+	frames := d.runner.Frames()
+	depth := len(frames)
+	frame := frames[depth-1]
+
+	if !d.control.ShouldPause(Breakpoint{File: frame.File, Line: frame.Location.Row}, depth) {
+		return true
+	}
+
+	d.printLine(frame.File, frame.Location.Row)
+	if !d.prompt(scope, depth) {
+		d.stopped = true
+		return false
+	}
+
+	return true
+}
+
+// Read commands until one resumes the program.
+// Returns false when it should stop.
+func (d *Debugger) prompt(scope *interpreter.Scope, depth int) bool {
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+
+		line, err := d.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(d.out)
+			return false
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = d.last
+		}
+		d.last = line
+
+		command, argument := line, ""
+		if space := strings.IndexAny(line, " \t"); space != -1 {
+			command, argument = line[:space], strings.TrimSpace(line[space+1:])
+		}
+
+		switch command {
+		case "c", "continue":
+			d.control.Resume(Continue, depth)
+			return true
+		case "s", "step":
+			d.control.Resume(StepIn, depth)
+			return true
+		case "n", "next":
+			d.control.Resume(StepOver, depth)
+			return true
+		case "o", "out":
+			d.control.Resume(StepOut, depth)
+			return true
+		case "b", "break":
+			d.setBreakpoint(argument)
+		case "d", "delete":
+			d.deleteBreakpoint(argument)
+		case "bt", "stack":
+			d.printStack()
+		case "scope":
+			d.printScope(scope)
+		case "p", "print":
+			d.evaluate(argument, scope)
+		case "l", "list":
+			d.printList()
+		case "q", "quit":
+			return false
+		case "h", "help":
+			fmt.Fprintln(d.out, help)
+		case "":
+		default:
+			d.printError(fmt.Sprintf("Unknown command '%s', see help", command))
+		}
+	}
+}
+
+// Set a breakpoint from LINE or FILE:LINE, or
+// list them without an argument.
+func (d *Debugger) setBreakpoint(argument string) {
+	if argument == "" {
+		for _, at := range d.control.Breakpoints() {
+			fmt.Fprintf(d.out, "%s:%d\n", at.File, at.Line)
+		}
+		return
+	}
+
+	at, ok := d.parseBreakpoint(argument)
+	if !ok {
+		return
+	}
+
+	d.control.SetBreakpoint(at)
+	fmt.Fprintf(d.out, "Breakpoint at %s:%d\n", at.File, at.Line)
+}
+
+// Delete a breakpoint.
+func (d *Debugger) deleteBreakpoint(argument string) {
+	at, ok := d.parseBreakpoint(argument)
+	if !ok {
+		return
+	}
+
+	if !d.control.ClearBreakpoint(at) {
+		d.printError(fmt.Sprintf("No breakpoint at %s:%d", at.File, at.Line))
+		return
+	}
+
+	fmt.Fprintf(d.out, "Deleted breakpoint at %s:%d\n", at.File, at.Line)
+}
+
+// Parse LINE or FILE:LINE.
+func (d *Debugger) parseBreakpoint(argument string) (Breakpoint, bool) {
+	file, line := d.file, argument
+	if colon := strings.LastIndex(argument, ":"); colon != -1 {
+		file, line = argument[:colon], argument[colon+1:]
+	}
+
+	number, err := strconv.Atoi(line)
+	if err != nil || number < 1 {
+		d.printError(fmt.Sprintf("Invalid line '%s'", argument))
+		return Breakpoint{}, false
+	}
+
+	return Breakpoint{File: file, Line: number}, true
+}
+
+// Print the call stack, innermost call first.
+func (d *Debugger) printStack() {
+	frames := d.runner.Frames()
+	for index := len(frames) - 1; index >= 0; index-- {
+		frame := frames[index]
+		fmt.Fprintf(d.out, "#%d %s at %s:%d\n", len(frames)-1-index, frame.Name, frame.File, frame.Location.Row)
+	}
+}
+
+// Print the variables of every scope in the chain,
+// innermost first.
+func (d *Debugger) printScope(scope *interpreter.Scope) {
+	level := 0
+	for current := scope; current != nil; current = current.Parent() {
+		locals := current.Locals()
+		if len(locals) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(d.out, "Scope #%d\n", level)
+		for _, name := range locals {
+			value, _ := current.Read(name)
+			fmt.Fprintf(d.out, "  %s = %s\n", name, value.Inspect())
+		}
+		level++
+	}
+}
+
+// Evaluate an expression in a child of the paused
+// scope, so declarations don't leak into the program.
+func (d *Debugger) evaluate(source string, scope *interpreter.Scope) {
+	if source == "" {
+		d.printError("Print expects an expression")
+		return
+	}
+
+	program := parser.New(lexer.New(reader.New([]byte(source)))).Parse()
+	if d.printErrors() {
+		return
+	}
+
+	result := d.runner.Interpret(program, interpreter.NewScopeFrom(scope))
+	if d.printErrors() {
+		return
+	}
+
+	if result != nil {
+		fmt.Fprintln(d.out, result.Inspect())
+	}
+}
+
+// Print the code around the current line.
+func (d *Debugger) printList() {
+	frames := d.runner.Frames()
+	frame := frames[len(frames)-1]
+	lines := d.source(frame.File)
+
+	start, end := frame.Location.Row-listContext, frame.Location.Row+listContext
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	for line := start; line <= end; line++ {
+		marker := "  "
+		if line == frame.Location.Row {
+			marker = "=>"
+		} else if d.control.HasBreakpoint(Breakpoint{File: frame.File, Line: line}) {
+			marker = " *"
+		}
+		fmt.Fprintf(d.out, "%s %4d  %s\n", marker, line, lines[line-1])
+	}
+}
+
+// Print where the program is paused.
+func (d *Debugger) printLine(file string, line int) {
+	code := ""
+	if lines := d.source(file); line >= 1 && line <= len(lines) {
+		code = strings.TrimSpace(lines[line-1])
+	}
+
+	color.New(color.FgYellow).Fprintf(d.out, "%s:%d", file, line)
+	fmt.Fprintf(d.out, "  %s\n", code)
+}
+
+// Lines of a source file, read once.
+func (d *Debugger) source(file string) []string {
+	if lines, ok := d.sources[file]; ok {
+		return lines
+	}
+
+	source, _ := ioutil.ReadFile(file)
+	d.sources[file] = strings.Split(string(source), "\n")
+
+	return d.sources[file]
+}
+
+// Print and clear the reported errors, if any.
+func (d *Debugger) printErrors() bool {
+	if !reporter.HasErrors() {
+		return false
+	}
+
+	for _, err := range reporter.GetErrors() {
+		d.printError(err)
+	}
+	reporter.ClearErrors()
+
+	return true
+}
+
+// Print an error in red.
+func (d *Debugger) printError(message string) {
+	color.New(color.FgRed).Fprintln(d.out, message)
+}
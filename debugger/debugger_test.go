@@ -0,0 +1,95 @@
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testSource = `let double = func (x)
+  let y = x * 2
+  y
+end
+var total = 0
+total = double(2)
+total = double(total)
+total`
+
+// Debug the test source with commands, returning
+// the output.
+func debug(commands string, breakpoints ...int) string {
+	var out bytes.Buffer
+	d := New("test.ari", strings.NewReader(commands), &out)
+	for _, line := range breakpoints {
+		d.Break(line)
+	}
+	d.Run([]byte(testSource))
+
+	return out.String()
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		commands string
+		expected []string
+	}{
+		{"c\n", []string{"test.ari:1  let double = func (x)", "Program finished"}},
+		{"n\nn\nn\n\nq\n", []string{"test.ari:5", "test.ari:6", "test.ari:7", "test.ari:8", "Program stopped"}},
+		{"b 2\nc\nbt\nc\nc\n", []string{"Breakpoint at test.ari:2", "test.ari:2  let y = x * 2", "#0 double at test.ari:2\n#1 main at test.ari:6", "Program finished"}},
+		{"b 6\nc\ns\ns\no\n", []string{"test.ari:6", "test.ari:2", "test.ari:3", "test.ari:7"}},
+		{"b 3\nc\nscope\np y + x\np let z = 1\np z\nq\n", []string{"Scope #0\n  x = 2\n  y = 4\nScope #1\n  double = ", "  total = 0\n", "6\n", "Identifier 'z' not found"}},
+		{"b 3\nb\nd 3\nd 3\nb x\nc\n", []string{"test.ari:3\n", "Deleted breakpoint at test.ari:3", "No breakpoint at test.ari:3", "Invalid line 'x'", "Program finished"}},
+		{"nope\nl\n", []string{"Unknown command 'nope'", "=>    1  let double", "      6  total = double(2)", "Program stopped"}},
+	}
+
+	for _, test := range tests {
+		output := debug(test.commands)
+		for _, expected := range test.expected {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected %q in the output of %q but got %q", expected, test.commands, output)
+			}
+		}
+	}
+}
+
+func TestBreakpointsWithoutPause(t *testing.T) {
+	output := debug("c\np total\nc\n", 7)
+	if !strings.Contains(output, "test.ari:7") || !strings.Contains(output, "(debug) 4\n") {
+		t.Errorf("Expected a pause at line 7 with total 4 but got %q", output)
+	}
+}
+
+func TestControl(t *testing.T) {
+	c := NewControl()
+	at := Breakpoint{File: "a.ari", Line: 3}
+
+	if !c.ShouldPause(Breakpoint{File: "a.ari", Line: 1}, 1) {
+		t.Errorf("Expected to pause at the first statement")
+	}
+
+	c.SetBreakpoint(at)
+	c.SetBreakpoint(Breakpoint{File: "b.ari", Line: 1})
+	c.Resume(Continue, 1)
+	if c.ShouldPause(Breakpoint{File: "a.ari", Line: 2}, 1) || !c.ShouldPause(at, 3) {
+		t.Errorf("Expected to pause only at breakpoints")
+	}
+
+	c.Resume(StepOver, 2)
+	if c.ShouldPause(Breakpoint{File: "a.ari", Line: 8}, 3) || !c.ShouldPause(Breakpoint{File: "a.ari", Line: 9}, 2) {
+		t.Errorf("Expected to step over deeper calls")
+	}
+
+	c.Resume(StepOut, 2)
+	if c.ShouldPause(Breakpoint{File: "a.ari", Line: 9}, 2) || !c.ShouldPause(Breakpoint{File: "a.ari", Line: 10}, 1) {
+		t.Errorf("Expected to step out of the current call")
+	}
+
+	c.ClearFile("b.ari")
+	if breakpoints := c.Breakpoints(); len(breakpoints) != 1 || breakpoints[0] != at {
+		t.Errorf("Expected only %v but got %v", at, breakpoints)
+	}
+
+	if !c.ClearBreakpoint(at) || c.ClearBreakpoint(at) {
+		t.Errorf("Expected to clear the breakpoint once")
+	}
+}
@@ -0,0 +1,385 @@
+package decimal
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionScale is the minimum number of fractional
+// digits kept when a division doesn't end.
+const DivisionScale = 16
+
+// The largest exponent a literal can have, so one like
+// 1e999999999 doesn't expand to as many zeros.
+const maxExponent = 1 << 20
+
+// RoundingMode decides what happens to the digits
+// dropped when rounding.
+type RoundingMode int
+
+// Rounding modes.
+const (
+	HalfEven RoundingMode = iota // To nearest, ties to even.
+	HalfUp                       // To nearest, ties away from zero.
+	HalfDown                     // To nearest, ties towards zero.
+	Up                           // Away from zero.
+	Down                         // Towards zero.
+	Ceiling                      // Towards positive infinity.
+	Floor                        // Towards negative infinity.
+)
+
+// Names of the rounding modes as used in
+// source code.
+var roundingModes = map[string]RoundingMode{
+	"halfEven": HalfEven,
+	"halfUp":   HalfUp,
+	"halfDown": HalfDown,
+	"up":       Up,
+	"down":     Down,
+	"ceiling":  Ceiling,
+	"floor":    Floor,
+}
+
+// LookupRoundingMode returns a rounding mode by name.
+func LookupRoundingMode(name string) (RoundingMode, bool) {
+	mode, ok := roundingModes[name]
+	return mode, ok
+}
+
+// Decimal is an exact base 10 number, represented as
+// unscaled * 10^-scale. Values are immutable: every
+// operation returns a new Decimal.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// New builds a Decimal from its unscaled value and scale.
+func New(unscaled *big.Int, scale int32) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(unscaled), scale: scale}
+}
+
+// FromInt builds a Decimal from an integer.
+func FromInt(value *big.Int) Decimal {
+	return New(value, 0)
+}
+
+// FromFloat builds a Decimal from the shortest representation
+// of a float that reads back as the same float.
+func FromFloat(value float64) (Decimal, error) {
+	return Parse(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Parse reads a Decimal from a string like "12.50", "-3"
+// or "1.5e3". Underscores are ignored.
+func Parse(str string) (Decimal, error) {
+	original := str
+	str = strings.Replace(str, "_", "", -1)
+
+	// Split the exponent from the mantissa.
+	var exponent int64
+	if idx := strings.IndexAny(str, "eE"); idx != -1 {
+		exp, err := strconv.ParseInt(str[idx+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal '%s'", original)
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return Decimal{}, fmt.Errorf("decimal '%s' is out of range", original)
+		}
+		exponent = exp
+		str = str[:idx]
+	}
+
+	scale := int64(0)
+	if idx := strings.Index(str, "."); idx != -1 {
+		scale = int64(len(str) - idx - 1)
+		str = str[:idx] + str[idx+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal '%s'", original)
+	}
+
+	scale -= exponent
+	// A negative scale means trailing zeros.
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// Scale returns the number of fractional digits.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1.
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	left, right, scale := align(d, other)
+	return Decimal{unscaled: left.Add(left, right), scale: scale}
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	left, right, scale := align(d, other)
+	return Decimal{unscaled: left.Sub(left, right), scale: scale}
+}
+
+// Mul returns d * other.
+func (d Decimal) Mul(other Decimal) Decimal {
+	unscaled := new(big.Int).Mul(d.value(), other.value())
+	return Decimal{unscaled: unscaled, scale: d.scale + other.scale}
+}
+
+// Quo returns d / other. Divisions that don't end are
+// rounded half to even at DivisionScale digits, and
+// trailing zeros beyond the operands' scale are dropped.
+func (d Decimal) Quo(other Decimal) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("Division by 0")
+	}
+
+	scale := d.scale
+	if other.scale > scale {
+		scale = other.scale
+	}
+	minScale := scale
+	if scale < DivisionScale {
+		scale = DivisionScale
+	}
+
+	// d / other at the given scale is:
+	// d.unscaled * 10^(scale - d.scale + other.scale) / other.unscaled
+	numerator := new(big.Int).Mul(d.value(), pow10(int64(scale-d.scale+other.scale)))
+	result := divide(numerator, other.value(), HalfEven)
+
+	return Decimal{unscaled: result, scale: scale}.trim(minScale), nil
+}
+
+// Mod returns the remainder of d / other, with the
+// sign of d.
+func (d Decimal) Mod(other Decimal) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("Division by 0")
+	}
+
+	left, right, scale := align(d, other)
+	return Decimal{unscaled: left.Rem(left, right), scale: scale}, nil
+}
+
+// Pow returns d raised to an integer exponent.
+func (d Decimal) Pow(exponent int64) (Decimal, error) {
+	if exponent < 0 {
+		if exponent == math.MinInt64 {
+			return Decimal{}, fmt.Errorf("Exponentiation result is out of range")
+		}
+		positive, err := d.Pow(-exponent)
+		if err != nil {
+			return Decimal{}, err
+		}
+		return FromInt(big.NewInt(1)).Quo(positive)
+	}
+
+	if bits := int64(d.value().BitLen()); bits > 0 && exponent > (1<<26)/bits {
+		return Decimal{}, fmt.Errorf("Exponentiation result is too large")
+	}
+
+	// The scale is multiplied too, and has to fit.
+	scale := int64(d.scale) * exponent
+	if scale > math.MaxInt32 || scale < math.MinInt32 {
+		return Decimal{}, fmt.Errorf("Exponentiation result is out of range")
+	}
+
+	unscaled := new(big.Int).Exp(d.value(), big.NewInt(exponent), nil)
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+// Cmp compares d and other, returning -1, 0 or 1.
+func (d Decimal) Cmp(other Decimal) int {
+	left, right, _ := align(d, other)
+	return left.Cmp(right)
+}
+
+// Round returns d rounded to the given number of
+// fractional digits.
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if places >= d.scale {
+		// Pad with zeros to the requested scale.
+		unscaled := new(big.Int).Mul(d.value(), pow10(int64(places-d.scale)))
+		return Decimal{unscaled: unscaled, scale: places}
+	}
+
+	divisor := pow10(int64(d.scale - places))
+	unscaled := divide(d.value(), divisor, mode)
+
+	// A negative number of places rounds to tens,
+	// hundreds and so on.
+	if places < 0 {
+		unscaled.Mul(unscaled, pow10(int64(-places)))
+		places = 0
+	}
+
+	return Decimal{unscaled: unscaled, scale: places}
+}
+
+// IsInteger checks if d has no fractional part.
+func (d Decimal) IsInteger() bool {
+	if d.scale <= 0 {
+		return true
+	}
+
+	return new(big.Int).Rem(d.value(), pow10(int64(d.scale))).Sign() == 0
+}
+
+// Int returns the integer part of d, truncated
+// towards zero.
+func (d Decimal) Int() *big.Int {
+	if d.scale <= 0 {
+		return new(big.Int).Mul(d.value(), pow10(int64(-d.scale)))
+	}
+
+	return new(big.Int).Quo(d.value(), pow10(int64(d.scale)))
+}
+
+// Float64 returns the closest float to d.
+func (d Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(d.String(), 64)
+	return value
+}
+
+// String prints d with all of its fractional digits,
+// so 12.50 stays "12.50".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.value()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+
+	if d.scale <= 0 {
+		return sign + digits + strings.Repeat("0", int(-d.scale))
+	}
+
+	// Pad with leading zeros so there's at least
+	// one digit before the point.
+	if len(digits) <= int(d.scale) {
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// The unscaled value, where the zero Decimal is 0.
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.unscaled
+}
+
+// Drop trailing zeros, but keep at least minScale
+// fractional digits.
+func (d Decimal) trim(minScale int32) Decimal {
+	unscaled := new(big.Int).Set(d.value())
+	scale := d.scale
+	ten := big.NewInt(10)
+	remainder := new(big.Int)
+
+	for scale > minScale {
+		quotient, rem := new(big.Int).QuoRem(unscaled, ten, remainder)
+		if rem.Sign() != 0 {
+			break
+		}
+		unscaled = quotient
+		scale--
+	}
+
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// Bring two decimals to the same scale, returning copies
+// of their unscaled values.
+func align(left, right Decimal) (*big.Int, *big.Int, int32) {
+	l := new(big.Int).Set(left.value())
+	r := new(big.Int).Set(right.value())
+
+	switch {
+	case left.scale > right.scale:
+		r.Mul(r, pow10(int64(left.scale-right.scale)))
+		return l, r, left.scale
+	case right.scale > left.scale:
+		l.Mul(l, pow10(int64(right.scale-left.scale)))
+		return l, r, right.scale
+	default:
+		return l, r, left.scale
+	}
+}
+
+// Integer division rounded with the given mode.
+func divide(numerator, denominator *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// The sign of the exact result decides which way
+	// is up and which is down.
+	negative := (numerator.Sign() < 0) != (denominator.Sign() < 0)
+
+	// Compare twice the remainder with the denominator to
+	// know if the dropped part is below, at or above half.
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(denominator))
+
+	awayFromZero := false
+	switch mode {
+	case HalfEven:
+		awayFromZero = half > 0 || half == 0 && quotient.Bit(0) == 1
+	case HalfUp:
+		awayFromZero = half >= 0
+	case HalfDown:
+		awayFromZero = half > 0
+	case Up:
+		awayFromZero = true
+	case Down:
+		awayFromZero = false
+	case Ceiling:
+		awayFromZero = !negative
+	case Floor:
+		awayFromZero = negative
+	}
+
+	if awayFromZero {
+		if negative {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return quotient
+}
+
+// 10 to the power of n.
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
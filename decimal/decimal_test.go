@@ -0,0 +1,140 @@
+package decimal
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12.50", "12.50"},
+		{"-0.05", "-0.05"},
+		{".5", "0.5"},
+		{"1_000.25", "1000.25"},
+		{"1.5e3", "1500"},
+		{"25e-3", "0.025"},
+	}
+
+	for _, test := range tests {
+		value, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Expected %s to parse but got %s", test.input, err)
+			continue
+		}
+
+		if value.String() != test.expected {
+			t.Errorf("Expected %s but got %s", test.expected, value.String())
+		}
+	}
+
+	for _, input := range []string{"", "-", "1.2.3", "abc", "1e", "1e999999999", "1e-999999999"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Expected %q to fail parsing", input)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, _ := Parse("10.25")
+	b, _ := Parse("0.5")
+
+	if result := a.Add(b).String(); result != "10.75" {
+		t.Errorf("Expected 10.75 but got %s", result)
+	}
+
+	if result := a.Sub(b).String(); result != "9.75" {
+		t.Errorf("Expected 9.75 but got %s", result)
+	}
+
+	if result := a.Mul(b).String(); result != "5.125" {
+		t.Errorf("Expected 5.125 but got %s", result)
+	}
+
+	if result, _ := a.Quo(b); result.String() != "20.50" {
+		t.Errorf("Expected 20.50 but got %s", result.String())
+	}
+
+	if _, err := a.Quo(Decimal{}); err == nil {
+		t.Errorf("Expected division by 0 to fail")
+	}
+
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Errorf("Expected 10.25 to compare higher than 0.5")
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		input    string
+		places   int32
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.5", 0, HalfEven, "2"},
+		{"3.5", 0, HalfEven, "4"},
+		{"2.5", 0, HalfUp, "3"},
+		{"2.5", 0, HalfDown, "2"},
+		{"-2.5", 0, HalfUp, "-3"},
+		{"2.1", 0, Up, "3"},
+		{"2.9", 0, Down, "2"},
+		{"-2.1", 0, Ceiling, "-2"},
+		{"-2.1", 0, Floor, "-3"},
+		{"1234.5", -2, HalfEven, "1200"},
+		{"1.5", 3, HalfEven, "1.500"},
+	}
+
+	for _, test := range tests {
+		value, _ := Parse(test.input)
+		if result := value.Round(test.places, test.mode).String(); result != test.expected {
+			t.Errorf("Expected %s rounded to be %s but got %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		input    string
+		exponent int64
+		expected string
+	}{
+		{"1.5", 2, "2.25"},
+		{"-2", 3, "-8"},
+		{"2", 0, "1"},
+		{"2", -2, "0.25"},
+	}
+
+	for _, test := range tests {
+		value, _ := Parse(test.input)
+		result, err := value.Pow(test.exponent)
+		if err != nil {
+			t.Errorf("Expected %s ** %d to succeed but got %s", test.input, test.exponent, err)
+			continue
+		}
+
+		if result.String() != test.expected {
+			t.Errorf("Expected %s ** %d to be %s but got %s", test.input, test.exponent, test.expected, result.String())
+		}
+	}
+
+	tiny, _ := Parse("1e-1000")
+	zero := New(big.NewInt(0), 1000)
+	two, _ := Parse("2")
+
+	for _, test := range []struct {
+		value    Decimal
+		exponent int64
+	}{
+		{tiny, 3000000},
+		{zero, 1 << 40},
+		{two, 1 << 62},
+		{two, math.MinInt64},
+	} {
+		if _, err := test.value.Pow(test.exponent); err == nil {
+			t.Errorf("Expected %s ** %d to fail", test.value.String(), test.exponent)
+		}
+	}
+}
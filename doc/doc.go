@@ -0,0 +1,140 @@
+// Package doc builds reference pages from the doc
+// comments of Aria modules and declarations, written
+// as Markdown or HTML.
+package doc
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/format"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/library"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"strings"
+)
+
+// LibraryTitle is the title of the page of the
+// Standard Library.
+const LibraryTitle = "Standard Library"
+
+// Page is the reference of a source file: its top
+// level declarations and its modules.
+type Page struct {
+	Title        string
+	Declarations []Declaration
+	Modules      []Module
+}
+
+// Module is a module with its members.
+type Module struct {
+	Name    string
+	Doc     string
+	Members []Declaration
+}
+
+// Declaration is a let declaration, with the signature
+// of the function it holds or its value.
+type Declaration struct {
+	Name      string
+	Doc       string
+	Signature string
+}
+
+// Source builds the page of source code.
+func Source(title string, source []byte) (*Page, error) {
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	program := parser.New(lexer.New(reader.New(source))).Parse()
+	if reporter.HasErrors() {
+		return nil, fmt.Errorf("Couldn't parse '%s': %s", title, strings.Join(reporter.GetErrors(), "; "))
+	}
+
+	page := &Page{Title: title}
+	addProgram(page, program)
+
+	return page, nil
+}
+
+// Library builds the page of the Standard Library,
+// with its modules in the order of their files.
+func Library() (*Page, error) {
+	page := &Page{Title: LibraryTitle}
+
+	for _, module := range library.Modules() {
+		modulePage, err := Source(module.File, module.Source)
+		if err != nil {
+			return nil, err
+		}
+		page.Modules = append(page.Modules, modulePage.Modules...)
+	}
+
+	return page, nil
+}
+
+// Add the top level declarations and modules of
+// a program to a page.
+func addProgram(page *Page, program *ast.Program) {
+	for _, statement := range program.Statements {
+		expression, ok := statement.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+
+		switch node := expression.Expression.(type) {
+		case *ast.Let:
+			page.Declarations = append(page.Declarations, declaration("", node))
+		case *ast.Module:
+			module := Module{Name: node.Name.Value, Doc: node.Doc}
+			for _, member := range node.Body.Statements {
+				if expression, ok := member.(*ast.ExpressionStatement); ok {
+					if let, ok := expression.Expression.(*ast.Let); ok {
+						module.Members = append(module.Members, declaration(module.Name+".", let))
+					}
+				}
+			}
+			page.Modules = append(page.Modules, module)
+		}
+	}
+}
+
+func declaration(prefix string, let *ast.Let) Declaration {
+	return Declaration{Name: prefix + let.Name.Value, Doc: let.Doc, Signature: Signature(prefix+let.Name.Value, let.Value)}
+}
+
+// Signature describes a declaration: the parameters
+// and return type of a function, with their type hints
+// and defaults, or the value of anything else.
+func Signature(name string, value ast.Expression) string {
+	function, ok := value.(*ast.Function)
+	if !ok {
+		if value == nil {
+			return name
+		}
+		return name + " = " + format.Expression(value)
+	}
+
+	parameters := []string{}
+	for index, parameter := range function.Parameters {
+		param := parameter.Name.Value
+		if function.Variadic && index == len(function.Parameters)-1 {
+			param = "..." + param
+		}
+		if parameter.Type != nil {
+			param += ": " + parameter.Type.Value
+		}
+		if parameter.Default != nil {
+			param += " = " + format.Expression(parameter.Default)
+		}
+		parameters = append(parameters, param)
+	}
+
+	signature := name + "(" + strings.Join(parameters, ", ") + ")"
+	if function.ReturnType != nil {
+		signature += " -> " + function.ReturnType.Value
+	}
+
+	return signature
+}
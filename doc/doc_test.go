@@ -0,0 +1,115 @@
+package doc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testSource = `/// Adds numbers.
+let add = func (a: Int, b = 2, ...rest) -> Int
+  a + b
+end
+
+let untold = 1
+
+/// Greetings.
+module Greet
+  /// Says hi to <someone>.
+  let hi = func (name = "you")
+    "hi " + name
+  end
+end`
+
+func TestSource(t *testing.T) {
+	page, err := Source("greet.ari", []byte(testSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Declaration{
+		{Name: "add", Doc: "Adds numbers.", Signature: "add(a: Int, b = 2, ...rest) -> Int"},
+		{Name: "untold", Signature: "untold = 1"},
+	}
+	if len(page.Declarations) != len(expected) {
+		t.Fatalf("Expected %d declarations but got %v", len(expected), page.Declarations)
+	}
+	for index, declaration := range expected {
+		if page.Declarations[index] != declaration {
+			t.Errorf("Expected %+v but got %+v", declaration, page.Declarations[index])
+		}
+	}
+
+	if len(page.Modules) != 1 || page.Modules[0].Doc != "Greetings." {
+		t.Fatalf("Expected the Greet module but got %+v", page.Modules)
+	}
+
+	member := Declaration{Name: "Greet.hi", Doc: "Says hi to <someone>.", Signature: `Greet.hi(name = "you")`}
+	if members := page.Modules[0].Members; len(members) != 1 || members[0] != member {
+		t.Errorf("Expected %+v but got %+v", member, members)
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	if _, err := Source("broken.ari", []byte("let = 1")); err == nil || !strings.Contains(err.Error(), "broken.ari") {
+		t.Errorf("Expected a parse error naming the file but got %v", err)
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	page, err := Library()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modules := map[string]Module{}
+	for _, module := range page.Modules {
+		modules[module.Name] = module
+	}
+
+	for _, name := range []string{"Enum", "Math", "String", "Dict", "Type"} {
+		module, ok := modules[name]
+		if !ok || module.Doc == "" {
+			t.Errorf("Expected the documented %s module but got %+v", name, module)
+			continue
+		}
+
+		for _, member := range module.Members {
+			if member.Doc == "" {
+				t.Errorf("Expected %s to be documented", member.Name)
+			}
+		}
+	}
+
+	if size := modules["Enum"].Members[0]; size.Signature != "Enum.size(array: Array) -> Int" {
+		t.Errorf("Expected the signature of Enum.size but got %q", size.Signature)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	page, _ := Source("greet.ari", []byte(testSource))
+
+	var out bytes.Buffer
+	Markdown(&out, page)
+
+	for _, expected := range []string{"# greet.ari\n", "## Declarations\n", "### add\n\n```aria\nadd(a: Int, b = 2, ...rest) -> Int\n```\n\nAdds numbers.\n", "## Greet\n\nGreetings.\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	page, _ := Source("greet.ari", []byte(testSource))
+
+	var out bytes.Buffer
+	if err := HTML(&out, page); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"<title>greet.ari</title>", `<h3 id="Greet.hi">Greet.hi</h3>`, "<p>Says hi to &lt;someone&gt;.</p>", "-&gt; Int"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package doc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Markdown writes a page as Markdown, with the
+// signatures in code blocks.
+func Markdown(w io.Writer, page *Page) {
+	fmt.Fprintf(w, "# %s\n", page.Title)
+
+	if len(page.Declarations) > 0 {
+		fmt.Fprintf(w, "\n## Declarations\n")
+		for _, declaration := range page.Declarations {
+			markdownDeclaration(w, declaration)
+		}
+	}
+
+	for _, module := range page.Modules {
+		fmt.Fprintf(w, "\n## %s\n", module.Name)
+		if module.Doc != "" {
+			fmt.Fprintf(w, "\n%s\n", module.Doc)
+		}
+
+		for _, member := range module.Members {
+			markdownDeclaration(w, member)
+		}
+	}
+}
+
+func markdownDeclaration(w io.Writer, declaration Declaration) {
+	fmt.Fprintf(w, "\n### %s\n\n```aria\n%s\n```\n", declaration.Name, declaration.Signature)
+	if declaration.Doc != "" {
+		fmt.Fprintf(w, "\n%s\n", declaration.Doc)
+	}
+}
+
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"paragraphs": paragraphs,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; line-height: 1.5; }
+pre { background: #f4f4f4; padding: 0.5em 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Declarations}}
+<h2>Declarations</h2>
+{{- range .Declarations}}{{template "declaration" .}}{{end}}
+{{- end}}
+{{- range .Modules}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+{{- range paragraphs .Doc}}
+<p>{{.}}</p>
+{{- end}}
+{{- range .Members}}{{template "declaration" .}}{{end}}
+{{- end}}
+</body>
+</html>
+{{define "declaration"}}
+<h3 id="{{.Name}}">{{.Name}}</h3>
+<pre><code>{{.Signature}}</code></pre>
+{{- range paragraphs .Doc}}
+<p>{{.}}</p>
+{{- end}}
+{{- end}}`))
+
+// HTML writes a page as a standalone HTML document.
+func HTML(w io.Writer, p *Page) error {
+	return page.Execute(w, p)
+}
+
+// Paragraphs of a doc comment, split at blank lines.
+func paragraphs(doc string) []string {
+	paragraphs := []string{}
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+
+	return paragraphs
+}
@@ -0,0 +1,169 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Lines of context around every change.
+const diffContext = 3
+
+// A line of the diff, prefixed by ' ', '-' or '+'.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// Diff returns a unified diff between the original and
+// formatted source, or nil if they're equal.
+func Diff(name string, original, formatted []byte) []byte {
+	if bytes.Equal(original, formatted) {
+		return nil
+	}
+
+	lines := diffLines(splitLines(original), splitLines(formatted))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s (formatted)\n", name, name)
+
+	// Walk the changes and group those closer than twice the
+	// context in the same hunk.
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+
+		to := start
+		for unchanged := 0; to < len(lines) && unchanged <= 2*diffContext; to++ {
+			if lines[to].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+
+		// Trim the trailing context to its size.
+		for to > start && lines[to-1].kind == ' ' {
+			to--
+		}
+		to += diffContext
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		writeHunk(&out, lines, from, to)
+		start = to
+	}
+
+	return out.Bytes()
+}
+
+// Write a hunk with its header of line numbers.
+func writeHunk(out *bytes.Buffer, lines []diffLine, from, to int) {
+	oldStart, newStart := 1, 1
+	for _, line := range lines[:from] {
+		if line.kind != '+' {
+			oldStart++
+		}
+		if line.kind != '-' {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, line := range lines[from:to] {
+		if line.kind != '+' {
+			oldCount++
+		}
+		if line.kind != '-' {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, line := range lines[from:to] {
+		out.WriteByte(line.kind)
+		out.WriteString(line.text)
+		out.WriteByte('\n')
+	}
+}
+
+// Compute the changes between two lists of lines from
+// their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	// Common prefix and suffix don't need the table.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	middleA := a[prefix : len(a)-suffix]
+	middleB := b[prefix : len(b)-suffix]
+
+	// lcs[i][j] is the length of the common subsequence
+	// of middleA[i:] and middleB[j:].
+	lcs := make([][]int, len(middleA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(middleB)+1)
+	}
+	for i := len(middleA) - 1; i >= 0; i-- {
+		for j := len(middleB) - 1; j >= 0; j-- {
+			if middleA[i] == middleB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+
+	i, j := 0, 0
+	for i < len(middleA) || j < len(middleB) {
+		switch {
+		case i < len(middleA) && j < len(middleB) && middleA[i] == middleB[j]:
+			lines = append(lines, diffLine{' ', middleA[i]})
+			i++
+			j++
+		case j == len(middleB) || (i < len(middleA) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', middleA[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', middleB[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+
+	return lines
+}
+
+// Split source in lines, ignoring the final line break.
+func splitLines(source []byte) []string {
+	text := strings.TrimSuffix(string(source), "\n")
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(text, "\n")
+}
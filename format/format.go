@@ -0,0 +1,642 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/token"
+	"reflect"
+	"strings"
+)
+
+// ErrSyntax is returned when the source doesn't parse. The
+// actual errors are left in the reporter.
+var ErrSyntax = errors.New("source has syntax errors")
+
+// Indentation of every block level.
+const indentation = "  "
+
+// Precedence of expressions that never need parentheses,
+// like literals and identifiers.
+const atomic = parser.AS + 1
+
+// Source formats Aria source code in its canonical layout.
+func Source(source []byte) ([]byte, error) {
+	lex := lexer.New(reader.New(source))
+	program := parser.New(lex).Parse()
+	if reporter.HasErrors() {
+		return nil, ErrSyntax
+	}
+
+	p := &printer{comments: lex.Comments()}
+	p.program(program)
+
+	return p.out.Bytes(), nil
+}
+
+// Expression formats a single expression, like the
+// default value of a parameter.
+func Expression(expression ast.Expression) string {
+	p := &printer{}
+	p.expression(expression)
+	p.newline()
+
+	return strings.TrimSuffix(p.out.String(), "\n")
+}
+
+// Printer writes the AST line by line, placing comments
+// by the source row they were found on.
+type printer struct {
+	out        bytes.Buffer
+	line       bytes.Buffer
+	indent     int
+	lineIndent int
+	comments   []token.Token
+	lastRow    int
+}
+
+// Print the top level statements.
+func (p *printer) program(program *ast.Program) {
+	p.statements(program.Statements)
+	p.leadingComments(-1, len(program.Statements) > 0)
+}
+
+// Print a list of statements, keeping single blank
+// lines where the source had any.
+func (p *printer) statements(statements []ast.Statement) {
+	for idx, statement := range statements {
+		first, last := span(statement)
+		separate := p.leadingComments(first, idx > 0) || idx > 0
+
+		if separate && first > p.lastRow+1 {
+			p.newline()
+		}
+
+		p.statement(statement)
+		p.mark(last)
+		p.newline()
+	}
+}
+
+// Print an indented block, including the comments
+// that come before its closing token.
+func (p *printer) block(block *ast.BlockStatement) {
+	p.indent++
+	p.statements(block.Statements)
+	p.leadingComments(block.End.Location.Row, len(block.Statements) > 0)
+	p.indent--
+	p.mark(block.End.Location.Row)
+}
+
+// Print a statement.
+func (p *printer) statement(statement ast.Statement) {
+	switch node := statement.(type) {
+	case *ast.ExpressionStatement:
+		p.expression(node.Expression)
+	case *ast.Return:
+		p.write("return ")
+		p.expression(node.Value)
+	case *ast.Break:
+		p.write("break")
+	case *ast.Continue:
+		p.write("continue")
+	}
+}
+
+// Print an expression.
+func (p *printer) expression(expression ast.Expression) {
+	if expression == nil {
+		return
+	}
+
+	p.mark(expression.TokenLocation().Row)
+
+	switch node := expression.(type) {
+	case *ast.Identifier:
+		p.write(node.Value)
+	case *ast.Integer, *ast.Float, *ast.Boolean:
+		p.write(node.TokenLexeme())
+	case *ast.Decimal:
+		p.write(node.Inspect())
+	case *ast.String:
+		p.write(quote(node.Value))
+	case *ast.Regex:
+		p.write(node.Inspect())
+	case *ast.Atom:
+		p.write(":" + node.Value)
+	case *ast.Nil:
+		p.write("nil")
+	case *ast.Placeholder:
+		p.write("_")
+	case *ast.Let:
+		p.write("let " + node.Name.Value + " = ")
+		p.expression(node.Value)
+	case *ast.Var:
+		p.write("var " + node.Name.Value + " = ")
+		p.expression(node.Value)
+	case *ast.Array:
+		p.write("[")
+		p.list(node.List.Elements)
+		p.write("]")
+	case *ast.Dictionary:
+		p.dictionary(node)
+	case *ast.Set:
+		p.write("#[")
+		p.list(node.List.Elements)
+		p.write("]")
+	case *ast.ExpressionList:
+		p.write("(")
+		p.list(node.Elements)
+		p.write(")")
+	case *ast.PrefixExpression:
+		p.write(node.Operator)
+		p.operand(node.Right, parser.PREFIX, false)
+	case *ast.InfixExpression:
+		precedence := precedenceOf(node)
+		p.operand(node.Left, precedence, isRightAssociative(node))
+		p.write(" " + node.Operator + " ")
+		p.operand(node.Right, precedence, !isRightAssociative(node))
+	case *ast.Assign:
+		p.assign(node)
+	case *ast.Pipe:
+		p.operand(node.Left, parser.PIPE, false)
+		p.write(" |> ")
+		p.operand(node.Right, parser.PIPE, true)
+	case *ast.Is:
+		p.postfix(node.Left, parser.Precedence(token.IS))
+		p.write(" is " + node.Right.Value)
+	case *ast.As:
+		p.postfix(node.Left, parser.AS)
+		p.write(" as " + node.Right.Value)
+	case *ast.ModuleAccess:
+		p.write(node.Object.Value + "." + node.Parameter.Value)
+	case *ast.FunctionCall:
+		p.postfix(node.Function, parser.CALL)
+		p.write("(")
+		p.list(node.Arguments.Elements)
+		p.write(")")
+	case *ast.Subscript:
+		p.subscript(node)
+	case *ast.If:
+		if node.Token.Type == token.QUESTION {
+			p.ternary(node)
+		} else {
+			p.ifExpression(node)
+		}
+	case *ast.Switch:
+		p.switchExpression(node)
+	case *ast.For:
+		p.forExpression(node)
+	case *ast.Function:
+		if node.Token.Type == token.ARROW {
+			p.arrowFunction(node)
+		} else {
+			p.function(node)
+		}
+	case *ast.Module:
+		p.write("module " + node.Name.Value)
+		p.newline()
+		p.block(node.Body)
+		p.write("end")
+	case *ast.Import:
+		p.write("import \"" + node.File.Value + "\"")
+	}
+}
+
+// Print a comma separated list of expressions.
+func (p *printer) list(elements []ast.Expression) {
+	for idx, element := range elements {
+		if idx > 0 {
+			p.write(", ")
+		}
+		p.expression(element)
+	}
+}
+
+// Print a dictionary literal.
+func (p *printer) dictionary(node *ast.Dictionary) {
+	if len(node.Pairs) == 0 {
+		p.write("[=>]")
+		return
+	}
+
+	p.write("[")
+	for idx, pair := range node.Pairs {
+		if idx > 0 {
+			p.write(", ")
+		}
+		p.expression(pair.Key)
+		p.write(" => ")
+		p.expression(pair.Value)
+	}
+	p.write("]")
+}
+
+// Print an assignment. Shorthand operators are parsed as
+// an infix expression on the right, which is unwrapped
+// to print the original.
+func (p *printer) assign(node *ast.Assign) {
+	p.expression(node.Name)
+	p.write(" " + node.Operator + " ")
+
+	right := node.Right
+	if infix, ok := right.(*ast.InfixExpression); ok && node.Operator != "=" {
+		right = infix.Right
+	}
+	p.expression(right)
+}
+
+// Print a subscript or slice.
+func (p *printer) subscript(node *ast.Subscript) {
+	p.postfix(node.Left, parser.INDEX)
+	p.write("[")
+
+	switch index := node.Index.(type) {
+	case *ast.Placeholder:
+	case *ast.Slice:
+		// Start and end are parsed up to the range
+		// operator, so anything lower needs grouping.
+		if index.Start != nil {
+			p.operand(index.Start, parser.RANGE+1, false)
+		}
+		p.write("..")
+		if index.End != nil {
+			p.operand(index.End, parser.RANGE+1, false)
+		}
+		if index.Step != nil {
+			p.write("..")
+			p.expression(index.Step)
+		}
+	default:
+		p.expression(index)
+	}
+
+	p.write("]")
+}
+
+// Print an if expression.
+func (p *printer) ifExpression(node *ast.If) {
+	p.write("if ")
+	p.expression(node.Condition)
+	p.newline()
+	p.block(node.Then)
+
+	if node.Else != nil {
+		p.mark(node.Else.Token.Location.Row)
+		p.write("else")
+		p.newline()
+		p.block(node.Else)
+	}
+
+	p.write("end")
+}
+
+// Print a for loop. Without an enumerable
+// it's an infinite loop.
+func (p *printer) forExpression(node *ast.For) {
+	p.write("for")
+	if node.Enumerable != nil {
+		p.write(" ")
+		for idx, argument := range node.Arguments.Elements {
+			if idx > 0 {
+				p.write(", ")
+			}
+			p.write(argument.Value)
+		}
+		p.write(" in ")
+		p.expression(node.Enumerable)
+	}
+	p.newline()
+	p.block(node.Body)
+	p.write("end")
+}
+
+// Print a ternary, which is parsed as an if
+// expression of single statements.
+func (p *printer) ternary(node *ast.If) {
+	p.operand(node.Condition, parser.TERNARY, true)
+	p.write(" ? ")
+	p.statement(node.Then.Statements[0])
+	p.write(" : ")
+	p.statement(node.Else.Statements[0])
+}
+
+// Print a switch expression.
+func (p *printer) switchExpression(node *ast.Switch) {
+	p.write("switch")
+	if node.Control != nil {
+		p.write(" ")
+		p.expression(node.Control)
+	}
+	p.newline()
+
+	for _, switchCase := range node.Cases {
+		p.mark(switchCase.Token.Location.Row)
+		p.write("case ")
+		p.list(switchCase.Values.Elements)
+		p.newline()
+		p.block(switchCase.Body)
+	}
+
+	if node.Default != nil {
+		p.mark(node.Default.Token.Location.Row)
+		p.write("default")
+		p.newline()
+		p.block(node.Default)
+	}
+
+	p.write("end")
+}
+
+// Print a function literal. Functions written on a single
+// line with a single statement stay that way.
+func (p *printer) function(node *ast.Function) {
+	p.write("func (")
+	for idx, parameter := range node.Parameters {
+		if idx > 0 {
+			p.write(", ")
+		}
+		if node.Variadic && idx == len(node.Parameters)-1 {
+			p.write("...")
+		}
+		p.write(parameter.Name.Value)
+		if parameter.Type != nil {
+			p.write(": " + parameter.Type.Value)
+		}
+		if parameter.Default != nil {
+			p.write(" = ")
+			p.expression(parameter.Default)
+		}
+	}
+	p.write(")")
+
+	if node.ReturnType != nil {
+		p.write(" -> " + node.ReturnType.Value)
+	}
+
+	if isInline(node) {
+		p.write(" do ")
+		p.statement(node.Body.Statements[0])
+		p.write(" end")
+		return
+	}
+
+	p.newline()
+	p.block(node.Body)
+	p.write("end")
+}
+
+// Print an arrow function.
+func (p *printer) arrowFunction(node *ast.Function) {
+	p.write("(")
+	for idx, parameter := range node.Parameters {
+		if idx > 0 {
+			p.write(", ")
+		}
+		p.write(parameter.Name.Value)
+	}
+	p.write(") -> ")
+	p.statement(node.Body.Statements[0])
+}
+
+// Check if a function was written on a single line
+// and can be printed as such.
+func isInline(node *ast.Function) bool {
+	if len(node.Body.Statements) != 1 || node.Token.Location.Row != node.Body.End.Location.Row {
+		return false
+	}
+
+	// Expressions with blocks are always expanded.
+	if statement, ok := node.Body.Statements[0].(*ast.ExpressionStatement); ok {
+		switch expression := statement.Expression.(type) {
+		case *ast.For, *ast.Switch, *ast.Module:
+			return false
+		case *ast.If:
+			return expression.Token.Type == token.QUESTION
+		}
+	}
+
+	return true
+}
+
+// Print an operand of an operator, adding parentheses when
+// the parser would otherwise bind it differently. Operands
+// on the tight side need grouping even at equal precedence.
+func (p *printer) operand(expression ast.Expression, precedence int, tight bool) {
+	own := precedenceOf(expression)
+	if own < precedence || (own == precedence && tight) {
+		p.group(expression)
+		return
+	}
+
+	p.expression(expression)
+}
+
+// Print the left side of a postfix operator like a call or
+// subscript. Other postfix expressions chain freely, as they
+// end with a closing token.
+func (p *printer) postfix(expression ast.Expression, precedence int) {
+	if isPostfix(expression) {
+		p.expression(expression)
+		return
+	}
+
+	p.operand(expression, precedence, false)
+}
+
+// Print an expression inside parentheses.
+func (p *printer) group(expression ast.Expression) {
+	p.write("(")
+	p.expression(expression)
+	p.write(")")
+}
+
+// Print the comments found before the given row on their
+// own lines. A row of -1 prints all of them.
+func (p *printer) leadingComments(row int, separate bool) bool {
+	printed := false
+
+	for len(p.comments) > 0 {
+		comment := p.comments[0]
+		if row != -1 && comment.Location.Row >= row {
+			break
+		}
+
+		p.comments = p.comments[1:]
+		if separate && comment.Location.Row > p.lastRow+1 {
+			p.newline()
+		}
+
+		p.write(comment.Lexeme)
+		p.mark(commentEnd(comment))
+		p.newline()
+		separate = true
+		printed = true
+	}
+
+	return printed
+}
+
+// Write text to the current line.
+func (p *printer) write(text string) {
+	if p.line.Len() == 0 {
+		p.lineIndent = p.indent
+	}
+
+	p.line.WriteString(text)
+}
+
+// Finish the current line. Comments that started on a row
+// already printed go at the end of it.
+func (p *printer) newline() {
+	for p.line.Len() > 0 && len(p.comments) > 0 && p.comments[0].Location.Row <= p.lastRow {
+		p.line.WriteString(" " + p.comments[0].Lexeme)
+		p.mark(commentEnd(p.comments[0]))
+		p.comments = p.comments[1:]
+	}
+
+	if p.line.Len() > 0 {
+		p.out.WriteString(strings.Repeat(indentation, p.lineIndent))
+		p.out.Write(p.line.Bytes())
+	}
+
+	p.out.WriteByte('\n')
+	p.line.Reset()
+}
+
+// Record the last source row that was printed.
+func (p *printer) mark(row int) {
+	if row > p.lastRow {
+		p.lastRow = row
+	}
+}
+
+// Precedence of an expression as the parser sees it.
+func precedenceOf(expression ast.Expression) int {
+	switch node := expression.(type) {
+	case *ast.InfixExpression:
+		// The only operator written as a keyword.
+		if node.Operator == "in" {
+			return parser.Precedence(token.IN)
+		}
+		return parser.Precedence(token.TokenType(node.Operator))
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.Assign:
+		return parser.ASSIGN
+	case *ast.Pipe:
+		return parser.PIPE
+	case *ast.Is:
+		return parser.Precedence(token.IS)
+	case *ast.As:
+		return parser.AS
+	case *ast.FunctionCall, *ast.ModuleAccess:
+		return parser.CALL
+	case *ast.Subscript:
+		return parser.INDEX
+	case *ast.If:
+		if node.Token.Type == token.QUESTION {
+			return parser.TERNARY
+		}
+	case *ast.Function:
+		if node.Token.Type == token.ARROW {
+			return parser.ARROW
+		}
+	case *ast.Let, *ast.Var:
+		return parser.LOWEST
+	}
+
+	return atomic
+}
+
+// Boolean operators are parsed with right associativity.
+func isRightAssociative(node *ast.InfixExpression) bool {
+	return node.Operator == "&&" || node.Operator == "||"
+}
+
+// Check if an expression ends with a closing token.
+func isPostfix(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.FunctionCall, *ast.Subscript, *ast.ModuleAccess, *ast.Is, *ast.As:
+		return true
+	default:
+		return false
+	}
+}
+
+// Quote a string literal. The lexer keeps escape sequences
+// but unescapes backslashes, so lone ones are escaped back.
+func quote(value string) string {
+	var out bytes.Buffer
+
+	out.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		out.WriteByte(value[i])
+		if value[i] == '\\' && (i+1 == len(value) || !strings.ContainsRune(`"ntrabfv`, rune(value[i+1]))) {
+			out.WriteByte('\\')
+		} else if value[i] == '\\' {
+			i++
+			out.WriteByte(value[i])
+		}
+	}
+	out.WriteByte('"')
+
+	return out.String()
+}
+
+// Last row of a comment, as multiline ones span several.
+func commentEnd(comment token.Token) int {
+	return comment.Location.Row + strings.Count(comment.Lexeme, "\n")
+}
+
+var (
+	tokenType = reflect.TypeOf(token.Token{})
+	astPath   = reflect.TypeOf(ast.Program{}).PkgPath()
+)
+
+// Rows of the first and last token of a node.
+func span(node interface{}) (int, int) {
+	first, last := 0, 0
+	walk(reflect.ValueOf(node), &first, &last)
+
+	return first, last
+}
+
+// Visit the tokens of a node, recursively.
+func walk(value reflect.Value, first, last *int) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			walk(value.Elem(), first, last)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			walk(value.Index(i), first, last)
+		}
+	case reflect.Struct:
+		if value.Type() == tokenType {
+			// Synthetic tokens have no location.
+			row := value.Interface().(token.Token).Location.Row
+			if row > 0 && (*first == 0 || row < *first) {
+				*first = row
+			}
+			if row > *last {
+				*last = row
+			}
+			return
+		}
+
+		// Values like big integers are not part of the tree.
+		if value.Type().PkgPath() != astPath {
+			return
+		}
+
+		for i := 0; i < value.NumField(); i++ {
+			walk(value.Field(i), first, last)
+		}
+	}
+}
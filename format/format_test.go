@@ -0,0 +1,141 @@
+package format
+
+import (
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a=1+2", "let a = 1 + 2\n"},
+		{"let add = func x, y\n      x+y\nend", "let add = func (x, y)\n  x + y\nend\n"},
+		{"let f = func x do x * 2 end", "let f = func (x) do x * 2 end\n"},
+		{"let v = func ...nums\nnums\nend", "let v = func (...nums)\n  nums\nend\n"},
+		{"let d = [\"a\"=>1,\"b\"=>2]", "let d = [\"a\" => 1, \"b\" => 2]\n"},
+		{"let e = [=>]", "let e = [=>]\n"},
+		{"[1 2 3][0..1]", "[1, 2, 3][0..1]\n"},
+		{"if a>1 then\nb\nelse\nc\nend", "if a > 1\n  b\nelse\n  c\nend\n"},
+		{"for k,v in d do\nk\nend", "for k, v in d\n  k\nend\n"},
+		{"switch a\n  case 1, 2\n    b\n  default\n    c\nend", "switch a\ncase 1, 2\n  b\ndefault\n  c\nend\n"},
+		{"x += 1", "x += 1\n"},
+		{"(1 + 2) * 3 - (4 - 5)", "(1 + 2) * 3 - (4 - 5)\n"},
+		{"-(2 ** 3)", "-(2 ** 3)\n"},
+		{"(a || b) && c", "(a || b) && c\n"},
+		{"a || (b && c)", "a || b && c\n"},
+		{"!(a is Int)", "!(a is Int)\n"},
+		{"(a ? b : c) ? d : e", "(a ? b : c) ? d : e\n"},
+		{"[1, 2] |> Enum.map(x -> x * 2)", "[1, 2] |> Enum.map((x) -> x * 2)\n"},
+		{"import math", "import \"math\"\n"},
+		{`"a\"b\\c"`, `"a\"b\\c"` + "\n"},
+		{"1.50d", "1.50d\n"},
+		{`~r"(\d+)\"\\"`, `~r"(\d+)\"\\"` + "\n"},
+		{"#[1,2]|#[3]", "#[1, 2] | #[3]\n"},
+		{"(x in s) == true", "x in s == true\n"},
+		{"x in (a | b)", "x in (a | b)\n"},
+		{"a\n\n\n\nb", "a\n\nb\n"},
+	}
+
+	for _, test := range tests {
+		actual, err := Source([]byte(test.input))
+		if err != nil {
+			t.Errorf("Expected %q to format but got errors %v", test.input, reporter.GetErrors())
+			reporter.ClearErrors()
+			continue
+		}
+
+		if string(actual) != test.expected {
+			t.Errorf("Expected %q but got %q", test.expected, string(actual))
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// header
+
+
+let a = 1 // trailing
+if a > 0 // condition
+     b()
+  // before end
+end
+
+/* block
+   comment */
+c()
+// footer`
+
+	expected := `// header
+
+let a = 1 // trailing
+if a > 0 // condition
+  b()
+  // before end
+end
+
+/* block
+   comment */
+c()
+// footer
+`
+
+	actual, err := Source([]byte(input))
+	if err != nil {
+		t.Fatalf("Expected source to format but got errors %v", reporter.GetErrors())
+	}
+
+	if string(actual) != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, string(actual))
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	if _, err := Source([]byte("let = 1")); err != ErrSyntax {
+		t.Errorf("Expected a syntax error")
+	}
+
+	reporter.ClearErrors()
+}
+
+func TestSourceInvalidRegex(t *testing.T) {
+	if _, err := Source([]byte(`~r"(a"`)); err != ErrSyntax {
+		t.Errorf("Expected an invalid regex to be a syntax error")
+	}
+
+	reporter.ClearErrors()
+}
+
+func TestExpression(t *testing.T) {
+	program := parser.New(lexer.New(reader.New([]byte(`["a", :b, 1 + 2]`)))).Parse()
+	expression := program.Statements[0].(*ast.ExpressionStatement).Expression
+
+	if actual := Expression(expression); actual != `["a", :b, 1 + 2]` {
+		t.Errorf("Expected the formatted array but got %q", actual)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if Diff("a.ari", []byte("a\n"), []byte("a\n")) != nil {
+		t.Errorf("Expected no diff for equal sources")
+	}
+
+	actual := string(Diff("a.ari", []byte("a\nb\nc\n"), []byte("a\nB\nc\n")))
+	expected := `--- a.ari
++++ a.ari (formatted)
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`
+
+	if actual != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
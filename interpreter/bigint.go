@@ -0,0 +1,80 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+)
+
+// Upper limit in bits for results of exponentiation
+// and shifting, which could easily grow out of hand.
+const maxBigIntegerBits = 1 << 26
+
+// Build an Integer from a big.Int, keeping it as a
+// native int64 whenever it fits.
+func newInteger(value *big.Int) *IntegerType {
+	if value.IsInt64() {
+		return &IntegerType{Value: value.Int64()}
+	}
+
+	clamped := int64(math.MaxInt64)
+	if value.Sign() < 0 {
+		clamped = math.MinInt64
+	}
+
+	return &IntegerType{Value: clamped, Big: value}
+}
+
+// IsBig checks if the Integer overflows int64.
+func (t *IntegerType) IsBig() bool {
+	return t.Big != nil
+}
+
+// BigValue returns the Integer as a big.Int. The result
+// is a new value that is safe to modify.
+func (t *IntegerType) BigValue() *big.Int {
+	if t.Big != nil {
+		return new(big.Int).Set(t.Big)
+	}
+
+	return big.NewInt(t.Value)
+}
+
+// FloatValue returns the Integer as a float64.
+func (t *IntegerType) FloatValue() float64 {
+	if t.Big != nil {
+		f, _ := new(big.Float).SetInt(t.Big).Float64()
+		return f
+	}
+
+	return float64(t.Value)
+}
+
+// Add two int64 and report if it overflowed.
+func addInt64(left, right int64) (int64, bool) {
+	result := left + right
+	overflow := (left > 0 && right > 0 && result < 0) || (left < 0 && right < 0 && result >= 0)
+
+	return result, overflow
+}
+
+// Subtract two int64 and report if it overflowed.
+func subInt64(left, right int64) (int64, bool) {
+	result := left - right
+	overflow := (right < 0 && result < left) || (right > 0 && result > left)
+
+	return result, overflow
+}
+
+// Multiply two int64 and report if it overflowed.
+func mulInt64(left, right int64) (int64, bool) {
+	if left == 0 || right == 0 {
+		return 0, false
+	}
+
+	result := left * right
+	overflow := result/right != left ||
+		(left == -1 && right == math.MinInt64) ||
+		(right == -1 && left == math.MinInt64)
+
+	return result, overflow
+}
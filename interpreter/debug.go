@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/token"
+)
+
+// LibraryFile is the file name given to code of
+// the Standard Library.
+const LibraryFile = "<library>"
+
+// Hook is called before a statement is interpreted.
+// Returning false stops the interpreter.
+type Hook func(node ast.Statement, scope *Scope) bool
+
+// Tracer follows every statement and function call,
+// including those of the Standard Library.
+type Tracer interface {
+	Step()
+	Enter(frame Frame)
+	Leave(frame Frame)
+}
+
+// Frame is a function call in progress, or the
+// top level of the program.
+type Frame struct {
+	Name     string
+	File     string
+	Location token.Location // Statement being interpreted.
+	Declared token.Location // Where the function was declared.
+	Scope    *Scope
+}
+
+// SetFile sets the name of the file being interpreted,
+// which functions and frames remember.
+func (i *Interpreter) SetFile(file string) {
+	i.file = file
+}
+
+// SetHook installs a function called before every
+// statement, except those of the Standard Library.
+func (i *Interpreter) SetHook(hook Hook) {
+	i.hook = hook
+}
+
+// SetTracer installs a Tracer, alongside any hook.
+func (i *Interpreter) SetTracer(tracer Tracer) {
+	i.tracer = tracer
+}
+
+// Frames returns the call stack, from the top level
+// to the innermost call.
+func (i *Interpreter) Frames() []Frame {
+	frames := []Frame{}
+	for _, frame := range i.frames {
+		frames = append(frames, *frame)
+	}
+
+	return frames
+}
+
+// Keep track of the statement about to run and
+// give it to the hook.
+func (i *Interpreter) step(node ast.Statement, scope *Scope) {
+	// Code run by the hook itself, like expressions
+	// evaluated by a debugger, isn't tracked.
+	if i.hooked {
+		return
+	}
+
+	if len(i.frames) == 0 {
+		i.frames = append(i.frames, &Frame{Name: "main"})
+	}
+
+	// Declarations of the Standard Library, run on
+	// behalf of a frame of another file, aren't part
+	// of it.
+	frame := i.frames[len(i.frames)-1]
+	if i.file == LibraryFile && frame.File != LibraryFile {
+		return
+	}
+
+	// The tracer sees the statement that just ended.
+	if i.tracer != nil {
+		i.tracer.Step()
+	}
+
+	frame.File = i.file
+	frame.Location = node.TokenLocation()
+	frame.Scope = scope
+
+	if i.hook == nil || frame.File == LibraryFile {
+		return
+	}
+
+	i.hooked = true
+	if !i.hook(node, scope) {
+		i.stopped = true
+	}
+	i.hooked = false
+}
+
+// Enter a function call, running in the file the
+// function was declared in. The returned function
+// leaves it.
+func (i *Interpreter) enterFrame(node *ast.FunctionCall, function *FunctionType, scope *Scope) func() {
+	name := "anonymous"
+	switch fn := node.Function.(type) {
+	case *ast.Identifier:
+		name = fn.Value
+	case *ast.ModuleAccess:
+		name = fn.Object.Value + "." + fn.Parameter.Value
+	}
+
+	file := i.file
+	i.file = function.File
+	frame := &Frame{Name: name, File: function.File, Location: function.Location, Declared: function.Location, Scope: scope}
+	i.frames = append(i.frames, frame)
+	if i.tracer != nil && !i.hooked {
+		i.tracer.Enter(*frame)
+	}
+
+	return func() {
+		if i.tracer != nil && !i.hooked {
+			i.tracer.Leave(*frame)
+		}
+		i.frames = i.frames[:len(i.frames)-1]
+		i.file = file
+	}
+}
+
+// Run a function while interpreting another file.
+func (i *Interpreter) inFile(file string, run func()) {
+	previous := i.file
+	i.file = file
+	run()
+	i.file = previous
+}
@@ -3,44 +3,109 @@ package interpreter
 import (
 	"fmt"
 	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/decimal"
 	"github.com/fadion/aria/lexer"
 	"github.com/fadion/aria/library"
 	"github.com/fadion/aria/parser"
 	"github.com/fadion/aria/reader"
 	"github.com/fadion/aria/reporter"
+	"io"
 	"io/ioutil"
 	"math"
+	"math/big"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // Interpreter represents the interpreter.
 type Interpreter struct {
-	modules         map[string]*ModuleType
-	moduleCache     map[string]map[string]DataType
-	importCache     map[string]DataType
-	immutables      map[string]*ast.Identifier
-	libraryFinished bool
+	modules     map[string]*ModuleType
+	moduleCache map[string]map[string]DataType
+	importCache map[string]DataType
+	immutables  map[string]*ast.Identifier
+	library     map[string]bool
+	graphemes   bool
+	file        string
+	hook        Hook
+	hooked      bool
+	tracer      Tracer
+	frames      []*Frame
+	stopped     bool
+	output      io.Writer
+	args        []string
+	osDisabled  bool
+	exit        func(code int)
+	regexes     map[string]*RegexType
 }
 
 // New initializes an Interpreter.
 func New() *Interpreter {
 	return &Interpreter{
-		modules:         map[string]*ModuleType{},
-		moduleCache:     map[string]map[string]DataType{},
-		importCache:     map[string]DataType{},
-		immutables:      map[string]*ast.Identifier{},
-		libraryFinished: false,
+		modules:     map[string]*ModuleType{},
+		moduleCache: map[string]map[string]DataType{},
+		importCache: map[string]DataType{},
+		immutables:  map[string]*ast.Identifier{},
+		regexes:     map[string]*RegexType{},
+		library:     map[string]bool{},
+		output:      os.Stdout,
 	}
 }
 
+// SetOutput sets where print and println write,
+// which is stdout by default.
+func (i *Interpreter) SetOutput(output io.Writer) {
+	i.output = output
+}
+
+// SetGraphemes makes string operations work on
+// grapheme clusters instead of runes.
+func (i *Interpreter) SetGraphemes(enabled bool) {
+	i.graphemes = enabled
+}
+
+// Modules returns the members of every declared
+// module, by module name. Standard Library modules
+// not used yet are loaded for their members.
+func (i *Interpreter) Modules() map[string][]string {
+	for _, module := range library.Modules() {
+		i.loadLibraryModule(module.Name)
+	}
+
+	modules := map[string][]string{}
+
+	for name, module := range i.modules {
+		members := []string{}
+		for _, statement := range module.Body.Statements {
+			if expression, ok := statement.(*ast.ExpressionStatement); ok {
+				if let, ok := expression.Expression.(*ast.Let); ok {
+					members = append(members, let.Name.Value)
+				}
+			}
+		}
+		modules[name] = members
+	}
+
+	return modules
+}
+
 // Interpret runs the interpreter.
 func (i *Interpreter) Interpret(node ast.Node, scope *Scope) DataType {
-	if err := i.importLibraryModules(scope); err != nil {
-		i.reportError(node, err.Error())
+	// A hook or OS.exit() stopped everything.
+	if i.stopped {
 		return nil
 	}
 
+	switch node := node.(type) {
+	case *ast.ExpressionStatement, *ast.Return, *ast.Break, *ast.Continue:
+		i.step(node.(ast.Statement), scope)
+		if i.stopped {
+			return nil
+		}
+	}
+
 	switch node := node.(type) {
 	case *ast.Program:
 		return i.runProgram(node, scope)
@@ -56,18 +121,27 @@ func (i *Interpreter) Interpret(node ast.Node, scope *Scope) DataType {
 		return i.runVar(node, scope)
 	case *ast.String:
 		return &StringType{Value: node.Value}
+	case *ast.Regex:
+		return i.runRegex(node)
 	case *ast.Atom:
 		return &AtomType{Value: node.Value}
 	case *ast.Integer:
+		if node.Big != nil {
+			return newInteger(node.Big)
+		}
 		return &IntegerType{Value: node.Value}
 	case *ast.Float:
 		return &FloatType{Value: node.Value}
+	case *ast.Decimal:
+		return &DecimalType{Value: node.Value}
 	case *ast.Boolean:
 		return i.nativeToBoolean(node.Value)
 	case *ast.Array:
 		return i.runArray(node, scope)
 	case *ast.Dictionary:
 		return i.runDictionary(node, scope)
+	case *ast.Set:
+		return i.runSet(node, scope)
 	case *ast.Nil:
 		return &NilType{}
 	case *ast.ExpressionStatement:
@@ -95,6 +169,8 @@ func (i *Interpreter) Interpret(node ast.Node, scope *Scope) DataType {
 			ReturnType: node.ReturnType,
 			Variadic:   node.Variadic,
 			Scope:      NewScopeFrom(scope),
+			File:       i.file,
+			Location:   node.Token.Location,
 		}
 	case *ast.FunctionCall:
 		return i.runFunction(node, scope)
@@ -119,30 +195,39 @@ func (i *Interpreter) Interpret(node ast.Node, scope *Scope) DataType {
 	return nil
 }
 
-func (i *Interpreter) importLibraryModules(scope *Scope) error {
-	// Library interpreted once.
-	if i.libraryFinished {
+// Load a Standard Library module the first time it's
+// needed, so modules that aren't used are never parsed.
+func (i *Interpreter) loadLibraryModule(name string) error {
+	if i.library[name] {
 		return nil
 	}
 
-	i.libraryFinished = true
+	module, ok := library.Find(name)
+	if !ok {
+		return nil
+	}
 
-	// Parse the source code of each module.
-	for _, v := range library.Modules {
-		lex := lexer.New(reader.New([]byte(v)))
-		if reporter.HasErrors() {
-			return fmt.Errorf("Problem reading Standard Library module")
-		}
+	i.library[name] = true
 
-		parse := parser.New(lex)
-		program := parse.Parse()
-		if reporter.HasErrors() {
-			return fmt.Errorf("Problem parsing Standard Library module")
-		}
+	return i.interpretLibraryModule(module)
+}
 
-		i.Interpret(program, scope)
+// Parse and interpret the source of a Standard Library
+// module, which declares it. Parse errors name the
+// library file and line they're at.
+func (i *Interpreter) interpretLibraryModule(module library.Module) error {
+	count := reporter.CountErrors()
+	program := parser.New(lexer.New(reader.New(module.Source))).Parse()
+	if reporter.CountErrors() > count {
+		report := reporter.GetReports()[count]
+		reporter.TruncateErrors(count)
+		return fmt.Errorf("Problem parsing Standard Library module %s:%d:%d: %s", module.File, report.Location.Row, report.Location.Col, report.Message)
 	}
 
+	i.inFile(LibraryFile, func() {
+		i.Interpret(program, NewScope())
+	})
+
 	return nil
 }
 
@@ -208,12 +293,19 @@ func (i *Interpreter) runVar(node *ast.Var, scope *Scope) DataType {
 
 // Interpret a Module.
 func (i *Interpreter) runModule(node *ast.Module, scope *Scope) DataType {
+	// Standard Library modules can't be redeclared,
+	// even when they're not loaded yet.
+	if err := i.loadLibraryModule(node.Name.Value); err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
 	if _, ok := i.modules[node.Name.Value]; ok {
 		i.reportError(node, fmt.Sprintf("Module '%s' redeclared", node.Name.Value))
 	} else {
 		// Store the module name and DataType for easier
 		// reference later.
-		i.modules[node.Name.Value] = &ModuleType{Name: node.Name, Body: node.Body}
+		i.modules[node.Name.Value] = &ModuleType{Name: node.Name, Body: node.Body, File: i.file}
 	}
 
 	return nil
@@ -223,6 +315,11 @@ func (i *Interpreter) runModule(node *ast.Module, scope *Scope) DataType {
 func (i *Interpreter) runModuleAccess(node *ast.ModuleAccess, scope *Scope) DataType {
 	scope = NewScope()
 
+	if err := i.loadLibraryModule(node.Object.Value); err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
 	// Check if the module exists.
 	if module, ok := i.modules[node.Object.Value]; ok {
 		// Check the cache for the required property
@@ -240,11 +337,18 @@ func (i *Interpreter) runModuleAccess(node *ast.ModuleAccess, scope *Scope) Data
 				case *ast.ExpressionStatement:
 					switch eType := sType.Expression.(type) {
 					case *ast.Let: // All module statements should be LET.
-						result := i.Interpret(statement, scope)
+						var result DataType
+						i.inFile(module.File, func() {
+							result = i.Interpret(statement, scope)
+						})
 						if result == nil {
 							return nil
 						}
 
+						if function, ok := result.(*FunctionType); ok && module.File == LibraryFile {
+							i.bindNative(function)
+						}
+
 						results[eType.Name.Value] = result
 					default:
 						i.reportError(node, "Only LET statements are accepted as Module members")
@@ -364,6 +468,10 @@ func (i *Interpreter) runAssign(node *ast.Assign, scope *Scope) DataType {
 
 // Interpret assignment for subscript.
 func (i *Interpreter) runAssignSubscript(node *ast.Subscript, original DataType, value DataType, scope *Scope) (DataType, error) {
+	if slice, ok := node.Index.(*ast.Slice); ok {
+		return i.runAssignSlice(slice, original, value, scope)
+	}
+
 	index := i.Interpret(node.Index, scope)
 
 	// No point in continuing if the
@@ -394,43 +502,113 @@ func (i *Interpreter) runAssignSubscript(node *ast.Subscript, original DataType,
 		return array, nil
 	case original.Type() == DICTIONARY_TYPE:
 		dictionary := original.(*DictionaryType)
-		found := false
-
-		// Check every key of the dictionary
-		// if it exists. If it doesn, update it.
-		for k := range dictionary.Pairs {
-			if k.Inspect() == index.Inspect() {
-				dictionary.Pairs[k] = value
-				found = true
-				break
-			}
-		}
 
-		// No actual key found, so the operation is
-		// considered an insert.
-		if !found {
-			dictionary.Pairs[index] = value
-		}
+		// Existing keys are updated in place, while
+		// new ones are appended at the end.
+		dictionary.Set(index, value)
 
 		return dictionary, nil
 	case original.Type() == STRING_TYPE && index.Type() == INTEGER_TYPE && value.Type() == STRING_TYPE:
-		str := original.(*StringType)
+		chars := i.splitString(original.(*StringType).Value)
 		idx := index.(*IntegerType).Value
 		value := value.(*StringType).Value
 
-		idx, err := i.checkStringBounds(str.Value, idx)
+		idx, err := i.checkStringBounds(chars, idx)
 		if err != nil {
 			return nil, err
 		}
 
 		// Create a new string by combining the two
 		// parts of the original string and the new one.
-		return &StringType{Value: str.Value[:idx] + value + str.Value[idx+1:]}, nil
+		chars[idx] = value
+		return &StringType{Value: strings.Join(chars, "")}, nil
 	default:
 		return nil, fmt.Errorf("Subscript assignment not recognised")
 	}
 }
 
+// Interpret assignment for slices: replaces the
+// sliced elements or characters with new ones.
+func (i *Interpreter) runAssignSlice(node *ast.Slice, original DataType, value DataType, scope *Scope) (DataType, error) {
+	var elements []DataType
+	var replacement []DataType
+
+	switch {
+	case original.Type() == ARRAY_TYPE && value.Type() == ARRAY_TYPE:
+		elements = original.(*ArrayType).Elements
+		replacement = value.(*ArrayType).Elements
+	case original.Type() == STRING_TYPE && value.Type() == STRING_TYPE:
+		elements = i.stringToArray(original.(*StringType)).Elements
+		replacement = i.stringToArray(value.(*StringType)).Elements
+	default:
+		return nil, fmt.Errorf("Slice assignment expects an Array or String of the same type")
+	}
+
+	indexes, err := i.sliceIndexes(node, len(elements), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DataType
+	if node.Step == nil {
+		// A contiguous slice is replaced by the new elements,
+		// no matter their count. An empty slice inserts them.
+		start, end := i.sliceBounds(indexes, node, len(elements), scope)
+		result = append(result, elements[:start]...)
+		result = append(result, replacement...)
+		result = append(result, elements[end:]...)
+	} else {
+		// Stepped slices can't grow or shrink.
+		if len(indexes) != len(replacement) {
+			return nil, fmt.Errorf("Slice assignment expects %d elements but got %d", len(indexes), len(replacement))
+		}
+
+		result = append(result, elements...)
+		for idx, pos := range indexes {
+			result[pos] = replacement[idx]
+		}
+	}
+
+	if original.Type() == STRING_TYPE {
+		return i.arrayToString(result), nil
+	}
+
+	array := original.(*ArrayType)
+	array.Elements = result
+
+	return array, nil
+}
+
+// Interpret a regex literal. The parser already
+// checked the pattern, and it's compiled only once.
+func (i *Interpreter) runRegex(node *ast.Regex) DataType {
+	regex, err := i.compileRegex(node.Value)
+	if err != nil {
+		i.reportError(node, fmt.Sprintf("Invalid regex: %s", err))
+		return nil
+	}
+
+	return regex
+}
+
+// Compile a pattern, or get it from those already
+// compiled.
+func (i *Interpreter) compileRegex(pattern string) (*RegexType, error) {
+	if regex, ok := i.regexes[pattern]; ok {
+		return regex, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	regex := &RegexType{Value: compiled}
+	i.regexes[pattern] = regex
+
+	return regex, nil
+}
+
 // Interpret an array.
 func (i *Interpreter) runArray(node *ast.Array, scope *Scope) DataType {
 	var result []DataType
@@ -445,19 +623,39 @@ func (i *Interpreter) runArray(node *ast.Array, scope *Scope) DataType {
 
 // Interpret a dictionary.
 func (i *Interpreter) runDictionary(node *ast.Dictionary, scope *Scope) DataType {
-	result := map[DataType]DataType{}
+	result := NewDictionary()
 
-	for k, v := range node.Pairs {
-		key := i.Interpret(k, scope)
+	for _, pair := range node.Pairs {
+		key := i.Interpret(pair.Key, scope)
 		if key == nil {
 			return nil
 		}
 
-		value := i.Interpret(v, scope)
-		result[key] = value
+		value := i.Interpret(pair.Value, scope)
+		if value == nil {
+			return nil
+		}
+
+		result.Set(key, value)
 	}
 
-	return &DictionaryType{Pairs: result}
+	return result
+}
+
+// Interpret a set.
+func (i *Interpreter) runSet(node *ast.Set, scope *Scope) DataType {
+	result := NewSet()
+
+	for _, element := range node.List.Elements {
+		value := i.Interpret(element, scope)
+		if value == nil {
+			return nil
+		}
+
+		result.Add(value)
+	}
+
+	return result
 }
 
 // Interpret an if/then/else expression.
@@ -571,10 +769,14 @@ func (i *Interpreter) runFor(node *ast.For, scope *Scope) DataType {
 	}
 
 	// For in loops are valid only for iteratables:
-	// Arrays, Dictionaries and Strings.
+	// Arrays, Sets, Dictionaries and Strings.
 	switch enum := enumObj.(type) {
 	case *ArrayType:
 		return i.runForArray(node, enum, scope)
+	case *SetType:
+		// Sets iterate like arrays, in the order
+		// their elements were added.
+		return i.runForArray(node, &ArrayType{Elements: enum.Elements}, scope)
 	case *DictionaryType:
 		return i.runForDictionary(node, enum, scope)
 	case *StringType:
@@ -671,7 +873,8 @@ func (i *Interpreter) runForArray(node *ast.For, array *ArrayType, scope *Scope)
 func (i *Interpreter) runForDictionary(node *ast.For, dictionary *DictionaryType, scope *Scope) DataType {
 	out := []DataType{}
 
-	for k, v := range dictionary.Pairs {
+	for _, pair := range dictionary.Pairs {
+		k, v := pair.Key, pair.Value
 		// Create a new scope for each iteration.
 		newscope := NewScopeFrom(scope)
 
@@ -717,6 +920,12 @@ func (i *Interpreter) runFunction(node *ast.FunctionCall, scope *Scope) DataType
 		if rfn, ok := runtime[nodeType.Value]; ok {
 			return i.runRuntimeFunction(node, rfn, scope)
 		}
+		if ifn, ok := intrinsics[nodeType.Value]; ok {
+			return ifn(i, node, scope)
+		}
+		if nfn, ok := natives[nodeType.Value]; ok {
+			return i.runNativeFunction(node, nfn, scope)
+		}
 	}
 
 	fn := i.Interpret(node.Function, scope)
@@ -724,6 +933,12 @@ func (i *Interpreter) runFunction(node *ast.FunctionCall, scope *Scope) DataType
 		return nil
 	}
 
+	return i.callFunction(node, fn, scope)
+}
+
+// Call a function value with the arguments of
+// the call expression.
+func (i *Interpreter) callFunction(node *ast.FunctionCall, fn DataType, scope *Scope) DataType {
 	// Make sure it's a function we're calling.
 	if fn.Type() != FUNCTION_TYPE {
 		i.reportError(node, "Trying to call a non-function")
@@ -826,7 +1041,22 @@ func (i *Interpreter) runFunction(node *ast.FunctionCall, scope *Scope) DataType
 		fnscope.Write(function.Parameters[len(function.Parameters)-1].Name.Value, &ArrayType{Elements: arguments})
 	}
 
-	result := i.unwrapReturnValue(i.Interpret(function.Body, fnscope))
+	// Native members run their Go function instead of
+	// the body. Errors are reported once out of the
+	// frame, so they point at the call.
+	var result DataType
+	var err error
+	leave := i.enterFrame(node, function, fnscope)
+	if function.Native != nil {
+		result, err = i.callNative(function, fnscope)
+	} else {
+		result = i.unwrapReturnValue(i.Interpret(function.Body, fnscope))
+	}
+	leave()
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
 	if result == nil {
 		return nil
 	}
@@ -867,6 +1097,20 @@ func (i *Interpreter) runRuntimeFunction(node *ast.FunctionCall, fn runtimeFunc,
 // Interpret an Array or Dictionary index call.
 func (i *Interpreter) runSubscript(node *ast.Subscript, scope *Scope) DataType {
 	left := i.Interpret(node.Left, scope)
+
+	if slice, ok := node.Index.(*ast.Slice); ok {
+		if left == nil {
+			return nil
+		}
+
+		result, err := i.runSlice(slice, left, scope)
+		if err != nil {
+			i.reportError(node, err.Error())
+			return nil
+		}
+		return result
+	}
+
 	index := i.Interpret(node.Index, scope)
 
 	// No point in continuing if any of the values
@@ -892,6 +1136,139 @@ func (i *Interpreter) runSubscript(node *ast.Subscript, scope *Scope) DataType {
 	}
 }
 
+// Interpret a slice of an Array or String.
+func (i *Interpreter) runSlice(node *ast.Slice, object DataType, scope *Scope) (DataType, error) {
+	var elements []DataType
+
+	switch object := object.(type) {
+	case *ArrayType:
+		elements = object.Elements
+	case *StringType:
+		elements = i.stringToArray(object).Elements
+	default:
+		return nil, fmt.Errorf("Slicing is supported only on Arrays and Strings, not '%s'", object.Type())
+	}
+
+	indexes, err := i.sliceIndexes(node, len(elements), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []DataType{}
+	for _, idx := range indexes {
+		result = append(result, elements[idx])
+	}
+
+	if object.Type() == STRING_TYPE {
+		return i.arrayToString(result), nil
+	}
+
+	return &ArrayType{Elements: result}, nil
+}
+
+// Find the positions a slice selects from an enumerable
+// of the given length. Bounds are inclusive, negative
+// values count from the end and out of bounds values are
+// clamped. A negative step slices backwards.
+func (i *Interpreter) sliceIndexes(node *ast.Slice, length int, scope *Scope) ([]int, error) {
+	step, err := i.sliceValue(node.Step, 1, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if step == 0 {
+		return nil, fmt.Errorf("Slice step can't be 0")
+	}
+
+	start, end := 0, length-1
+	if step < 0 {
+		start, end = length-1, 0
+	}
+
+	if start, err = i.sliceValue(node.Start, start, scope); err != nil {
+		return nil, err
+	}
+
+	if end, err = i.sliceValue(node.End, end, scope); err != nil {
+		return nil, err
+	}
+
+	// Negative bounds count from the end.
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+
+	indexes := []int{}
+
+	if step > 0 {
+		if start < 0 {
+			start = 0
+		}
+		if end > length-1 {
+			end = length - 1
+		}
+		for idx := start; idx <= end; idx += step {
+			indexes = append(indexes, idx)
+		}
+	} else {
+		if start > length-1 {
+			start = length - 1
+		}
+		if end < 0 {
+			end = 0
+		}
+		for idx := start; idx >= end; idx += step {
+			indexes = append(indexes, idx)
+		}
+	}
+
+	return indexes, nil
+}
+
+// Find the half-open bounds of a contiguous slice. An
+// empty slice gets an empty range at its start.
+func (i *Interpreter) sliceBounds(indexes []int, node *ast.Slice, length int, scope *Scope) (int, int) {
+	if len(indexes) > 0 {
+		return indexes[0], indexes[len(indexes)-1] + 1
+	}
+
+	start, _ := i.sliceValue(node.Start, 0, scope)
+	if start < 0 {
+		start += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+	if start > length {
+		start = length
+	}
+
+	return start, start
+}
+
+// Interpret one of the parts of a slice, falling
+// back to a default when it's missing.
+func (i *Interpreter) sliceValue(node ast.Expression, fallback int, scope *Scope) (int, error) {
+	if node == nil {
+		return fallback, nil
+	}
+
+	object := i.Interpret(node, scope)
+	if object == nil {
+		return 0, fmt.Errorf("Slice bound couldn't be interpreted")
+	}
+
+	if object.Type() != INTEGER_TYPE {
+		return 0, fmt.Errorf("Slice expects Integer bounds but got '%s'", object.Type())
+	}
+
+	return int(object.(*IntegerType).Value), nil
+}
+
 // Interpret an Array subscript.
 func (i *Interpreter) runArraySubscript(array, index DataType) DataType {
 	arrayObj := array.(*ArrayType).Elements
@@ -907,12 +1284,8 @@ func (i *Interpreter) runArraySubscript(array, index DataType) DataType {
 
 // Interpret a Dictionary subscript.
 func (i *Interpreter) runDictionarySubscript(dictionary, index DataType) DataType {
-	dictObj := dictionary.(*DictionaryType).Pairs
-
-	for k, v := range dictObj {
-		if k.Inspect() == index.Inspect() {
-			return v
-		}
+	if value, ok := dictionary.(*DictionaryType).Get(index); ok {
+		return value
 	}
 
 	return NIL
@@ -920,15 +1293,15 @@ func (i *Interpreter) runDictionarySubscript(dictionary, index DataType) DataTyp
 
 // Interpret a String subscript.
 func (i *Interpreter) runStringSubscript(str, index DataType) (DataType, error) {
-	stringObj := str.(*StringType).Value
+	chars := i.splitString(str.(*StringType).Value)
 	idx := index.(*IntegerType).Value
 
-	idx, err := i.checkStringBounds(stringObj, idx)
+	idx, err := i.checkStringBounds(chars, idx)
 	if err != nil {
 		return NIL, nil
 	}
 
-	return &StringType{Value: string(stringObj[idx])}, nil
+	return &StringType{Value: chars[idx]}, nil
 }
 
 // Interpret Pipe operator: FUNCTION_CALL() |> FUNCTION_CALL()
@@ -938,9 +1311,17 @@ func (i *Interpreter) runPipe(node *ast.Pipe, scope *Scope) DataType {
 	case *ast.FunctionCall:
 		// The left-hand expression is either a value or
 		// a pipe. In each case, it will be interpreted when
-		// the rightFunc will be called.
-		rightFunc.Arguments.Elements = append([]ast.Expression{node.Left}, rightFunc.Arguments.Elements...)
-		return i.Interpret(rightFunc, scope)
+		// the rightFunc will be called. The call is copied,
+		// so the same pipe can run more than once.
+		call := &ast.FunctionCall{
+			Token:    rightFunc.Token,
+			Function: rightFunc.Function,
+			Arguments: &ast.ExpressionList{
+				Token:    rightFunc.Arguments.Token,
+				Elements: append([]ast.Expression{node.Left}, rightFunc.Arguments.Elements...),
+			},
+		}
+		return i.Interpret(call, scope)
 	default:
 		i.reportError(node, "Pipe operatore expects a function on the right side")
 		return nil
@@ -974,7 +1355,10 @@ func (i *Interpreter) runImport(node *ast.Import, scope *Scope) DataType {
 		return nil
 	}
 
-	result := i.Interpret(program, scope)
+	var result DataType
+	i.inFile(filename, func() {
+		result = i.Interpret(program, scope)
+	})
 
 	// Cache the result.
 	i.importCache[filename] = result
@@ -1027,8 +1411,12 @@ func (i *Interpreter) runAs(node *ast.As, scope *Scope) DataType {
 		return i.runRuntimeFunction(nodeFunc, runtime["Int"], scope)
 	case "Float":
 		return i.runRuntimeFunction(nodeFunc, runtime["Float"], scope)
+	case "Decimal":
+		return i.runRuntimeFunction(nodeFunc, runtime["Decimal"], scope)
 	case "Array":
 		return i.runRuntimeFunction(nodeFunc, runtime["Array"], scope)
+	case "Set":
+		return i.runRuntimeFunction(nodeFunc, runtime["Set"], scope)
 	default:
 		i.reportError(node, fmt.Sprintf("Can't convert to type '%s'", node.Right.Value))
 		return nil
@@ -1069,11 +1457,18 @@ func (i *Interpreter) runPrefix(node *ast.PrefixExpression, scope *Scope) DataTy
 func (i *Interpreter) runMinusPrefix(object DataType) (DataType, error) {
 	switch object.Type() {
 	case INTEGER_TYPE:
-		return &IntegerType{Value: -object.(*IntegerType).Value}, nil
+		integer := object.(*IntegerType)
+		// The lowest int64 has no positive counterpart.
+		if integer.IsBig() || integer.Value == math.MinInt64 {
+			return newInteger(new(big.Int).Neg(integer.BigValue())), nil
+		}
+		return &IntegerType{Value: -integer.Value}, nil
 	case FLOAT_TYPE:
 		return &FloatType{Value: -object.(*FloatType).Value}, nil
+	case DECIMAL_TYPE:
+		return &DecimalType{Value: object.(*DecimalType).Value.Neg()}, nil
 	default:
-		return nil, fmt.Errorf("Minus prefix can be applied to Integers and Floats only")
+		return nil, fmt.Errorf("Minus prefix can be applied to Integers, Floats and Decimals only")
 	}
 }
 
@@ -1081,7 +1476,11 @@ func (i *Interpreter) runMinusPrefix(object DataType) (DataType, error) {
 func (i *Interpreter) runBitwiseNotPrefix(object DataType) (DataType, error) {
 	switch object.Type() {
 	case INTEGER_TYPE:
-		return &IntegerType{Value: ^object.(*IntegerType).Value}, nil
+		integer := object.(*IntegerType)
+		if integer.IsBig() {
+			return newInteger(new(big.Int).Not(integer.Big)), nil
+		}
+		return &IntegerType{Value: ^integer.Value}, nil
 	default:
 		return nil, fmt.Errorf("Bitwise NOT prefix can be applied to Integers only")
 	}
@@ -1109,84 +1508,127 @@ func (i *Interpreter) runInfix(node *ast.InfixExpression, scope *Scope) DataType
 		return nil
 	}
 
-	var out DataType
-	var err error
+	out, err := i.runOperator(node.Operator, left, right)
+	if err != nil {
+		i.reportError(node, err.Error())
+	}
+
+	return out
+}
 
-	// Infix operators have different meaning for different
-	// data types. Every possible combination of data type
-	// is checked and run in its own function.
+// Run an infix operator on two values. Infix operators
+// have different meaning for different data types. Every
+// possible combination of data type is checked and run
+// in its own function.
+func (i *Interpreter) runOperator(operator string, left, right DataType) (DataType, error) {
 	switch {
+	case operator == "in":
+		return i.runInInfix(left, right)
 	case left.Type() == INTEGER_TYPE && right.Type() == INTEGER_TYPE:
-		out, err = i.runIntegerInfix(node.Operator, left, right)
+		return i.runIntegerInfix(operator, left, right)
 	case left.Type() == FLOAT_TYPE && right.Type() == FLOAT_TYPE:
-		out, err = i.runFloatInfix(node.Operator, left.(*FloatType).Value, right.(*FloatType).Value)
+		return i.runFloatInfix(operator, left.(*FloatType).Value, right.(*FloatType).Value)
 	case left.Type() == FLOAT_TYPE && right.Type() == INTEGER_TYPE:
 		// Treat the integer as a float to allow
 		// operations between the two.
-		out, err = i.runFloatInfix(node.Operator, left.(*FloatType).Value, float64(right.(*IntegerType).Value))
+		return i.runFloatInfix(operator, left.(*FloatType).Value, right.(*IntegerType).FloatValue())
 	case left.Type() == INTEGER_TYPE && right.Type() == FLOAT_TYPE:
 		// Same as above: treat the integer as a float.
-		out, err = i.runFloatInfix(node.Operator, float64(left.(*IntegerType).Value), right.(*FloatType).Value)
+		return i.runFloatInfix(operator, left.(*IntegerType).FloatValue(), right.(*FloatType).Value)
+	case left.Type() == DECIMAL_TYPE && right.Type() == DECIMAL_TYPE:
+		return i.runDecimalInfix(operator, left.(*DecimalType).Value, right.(*DecimalType).Value)
+	case left.Type() == DECIMAL_TYPE && right.Type() == INTEGER_TYPE:
+		// Integers are exact, so they're promoted
+		// to Decimal without losing anything.
+		return i.runDecimalInfix(operator, left.(*DecimalType).Value, decimal.FromInt(right.(*IntegerType).BigValue()))
+	case left.Type() == INTEGER_TYPE && right.Type() == DECIMAL_TYPE:
+		return i.runDecimalInfix(operator, decimal.FromInt(left.(*IntegerType).BigValue()), right.(*DecimalType).Value)
 	case left.Type() == STRING_TYPE && right.Type() == STRING_TYPE:
-		out, err = i.runStringInfix(node.Operator, left.(*StringType).Value, right.(*StringType).Value)
+		return i.runStringInfix(operator, left.(*StringType).Value, right.(*StringType).Value)
 	case left.Type() == ATOM_TYPE && right.Type() == ATOM_TYPE:
 		// Treat atoms as string.
-		out, err = i.runStringInfix(node.Operator, left.(*AtomType).Value, right.(*AtomType).Value)
+		return i.runStringInfix(operator, left.(*AtomType).Value, right.(*AtomType).Value)
 	case left.Type() == ATOM_TYPE && right.Type() == STRING_TYPE:
-		out, err = i.runStringInfix(node.Operator, left.(*AtomType).Value, right.(*StringType).Value)
+		return i.runStringInfix(operator, left.(*AtomType).Value, right.(*StringType).Value)
 	case left.Type() == STRING_TYPE && right.Type() == ATOM_TYPE:
-		out, err = i.runStringInfix(node.Operator, left.(*StringType).Value, right.(*AtomType).Value)
+		return i.runStringInfix(operator, left.(*StringType).Value, right.(*AtomType).Value)
+	case left.Type() == REGEX_TYPE && right.Type() == REGEX_TYPE:
+		return i.runRegexInfix(operator, left.(*RegexType), right.(*RegexType))
 	case left.Type() == BOOLEAN_TYPE && right.Type() == BOOLEAN_TYPE:
-		out, err = i.runBooleanInfix(node.Operator, left, right)
+		return i.runBooleanInfix(operator, left, right)
 	case left.Type() == ARRAY_TYPE && right.Type() == ARRAY_TYPE:
-		out, err = i.runArrayInfix(node.Operator, left, right)
+		return i.runArrayInfix(operator, left, right)
 	case left.Type() == DICTIONARY_TYPE && right.Type() == DICTIONARY_TYPE:
-		out, err = i.runDictionaryInfix(node.Operator, left, right)
+		return i.runDictionaryInfix(operator, left, right)
+	case left.Type() == SET_TYPE && right.Type() == SET_TYPE:
+		return i.runSetInfix(operator, left.(*SetType), right.(*SetType))
 	case left.Type() == NIL_TYPE || right.Type() == NIL_TYPE:
-		out, err = i.runNilInfix(node.Operator, left, right)
+		return i.runNilInfix(operator, left, right)
 	case left.Type() != right.Type():
-		err = fmt.Errorf("Cannot run expression with types '%s' and '%s'", left.Type(), right.Type())
+		return nil, fmt.Errorf("Cannot run expression with types '%s' and '%s'", left.Type(), right.Type())
 	default:
-		err = fmt.Errorf("Uknown operator %s for types '%s' and '%s'", node.Operator, left.Type(), right.Type())
+		return nil, fmt.Errorf("Uknown operator %s for types '%s' and '%s'", operator, left.Type(), right.Type())
 	}
-
-	if err != nil {
-		i.reportError(node, err.Error())
-	}
-
-	return out
 }
 
 // Interpret infix operation for Integers.
 func (i *Interpreter) runIntegerInfix(operator string, left, right DataType) (DataType, error) {
-	leftVal := left.(*IntegerType).Value
-	rightVal := right.(*IntegerType).Value
+	leftInt := left.(*IntegerType)
+	rightInt := right.(*IntegerType)
+
+	// Big integers don't fit the native operations,
+	// so they're run with arbitrary precision.
+	if leftInt.IsBig() || rightInt.IsBig() {
+		return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
+	}
+
+	leftVal := leftInt.Value
+	rightVal := rightInt.Value
 
 	switch operator {
 	case "+":
-		return &IntegerType{Value: leftVal + rightVal}, nil
+		if result, overflow := addInt64(leftVal, rightVal); !overflow {
+			return &IntegerType{Value: result}, nil
+		}
+		return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
 	case "-":
-		return &IntegerType{Value: leftVal - rightVal}, nil
+		if result, overflow := subInt64(leftVal, rightVal); !overflow {
+			return &IntegerType{Value: result}, nil
+		}
+		return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
 	case "*":
-		return &IntegerType{Value: leftVal * rightVal}, nil
+		if result, overflow := mulInt64(leftVal, rightVal); !overflow {
+			return &IntegerType{Value: result}, nil
+		}
+		return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
 	case "/":
 		// Division by zero.
 		if rightVal == 0 {
 			return nil, fmt.Errorf("Division by 0")
 		}
 
-		value := float64(leftVal) / float64(rightVal)
-		// Check if it's a full number, so it can be returned
-		// as an Integer object. Otherwise it will be a Float object.
-		if math.Trunc(value) == value {
-			return &IntegerType{Value: int64(value)}, nil
+		// Exact divisions stay Integers. Otherwise
+		// the result will be a Float.
+		if leftVal%rightVal == 0 {
+			if leftVal == math.MinInt64 && rightVal == -1 {
+				return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
+			}
+			return &IntegerType{Value: leftVal / rightVal}, nil
 		}
 
-		return &FloatType{Value: value}, nil
+		return &FloatType{Value: float64(leftVal) / float64(rightVal)}, nil
 	case "%":
+		if rightVal == 0 {
+			return nil, fmt.Errorf("Division by 0")
+		}
 		return &IntegerType{Value: leftVal % rightVal}, nil
 	case "**": // Exponentiation
-		return &IntegerType{Value: int64(math.Pow(float64(leftVal), float64(rightVal)))}, nil
+		// Negative exponents keep the integer part
+		// of the result.
+		if rightVal < 0 {
+			return &IntegerType{Value: int64(math.Pow(float64(leftVal), float64(rightVal)))}, nil
+		}
+		return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
 	case "<":
 		return i.nativeToBoolean(leftVal < rightVal), nil
 	case "<=":
@@ -1200,7 +1642,7 @@ func (i *Interpreter) runIntegerInfix(operator string, left, right DataType) (Da
 		if leftVal < 0 || rightVal < 0 {
 			return nil, fmt.Errorf("Bitwise shift requires two unsigned Integers")
 		}
-		return &IntegerType{Value: int64(uint64(leftVal) << uint64(rightVal))}, nil
+		return i.runBigIntegerInfix(operator, leftInt.BigValue(), rightInt.BigValue())
 	case ">>":
 		if leftVal < 0 || rightVal < 0 {
 			return nil, fmt.Errorf("Bitwsise shift requires two unsigned Integers")
@@ -1221,6 +1663,86 @@ func (i *Interpreter) runIntegerInfix(operator string, left, right DataType) (Da
 	}
 }
 
+// Interpret infix operation for Integers with arbitrary
+// precision. Results that fit int64 are demoted back to
+// native Integers.
+func (i *Interpreter) runBigIntegerInfix(operator string, left, right *big.Int) (DataType, error) {
+	switch operator {
+	case "+":
+		return newInteger(left.Add(left, right)), nil
+	case "-":
+		return newInteger(left.Sub(left, right)), nil
+	case "*":
+		return newInteger(left.Mul(left, right)), nil
+	case "/":
+		if right.Sign() == 0 {
+			return nil, fmt.Errorf("Division by 0")
+		}
+
+		quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+		if remainder.Sign() == 0 {
+			return newInteger(quotient), nil
+		}
+
+		value, _ := new(big.Rat).SetFrac(left, right).Float64()
+		return &FloatType{Value: value}, nil
+	case "%":
+		if right.Sign() == 0 {
+			return nil, fmt.Errorf("Division by 0")
+		}
+		return newInteger(left.Rem(left, right)), nil
+	case "**":
+		if right.Sign() < 0 {
+			l, _ := new(big.Float).SetInt(left).Float64()
+			r, _ := new(big.Float).SetInt(right).Float64()
+			return &IntegerType{Value: int64(math.Pow(l, r))}, nil
+		}
+
+		// Guard against results that would take forever
+		// to calculate or exhaust the memory.
+		if !right.IsInt64() || int64(left.BitLen())*right.Int64() > maxBigIntegerBits {
+			return nil, fmt.Errorf("Exponentiation result is too large")
+		}
+		return newInteger(left.Exp(left, right, nil)), nil
+	case "<":
+		return i.nativeToBoolean(left.Cmp(right) < 0), nil
+	case "<=":
+		return i.nativeToBoolean(left.Cmp(right) <= 0), nil
+	case ">":
+		return i.nativeToBoolean(left.Cmp(right) > 0), nil
+	case ">=":
+		return i.nativeToBoolean(left.Cmp(right) >= 0), nil
+	case "<<":
+		if left.Sign() < 0 || right.Sign() < 0 {
+			return nil, fmt.Errorf("Bitwise shift requires two unsigned Integers")
+		}
+		if !right.IsInt64() || int64(left.BitLen())+right.Int64() > maxBigIntegerBits {
+			return nil, fmt.Errorf("Bitwise shift result is too large")
+		}
+		return newInteger(left.Lsh(left, uint(right.Int64()))), nil
+	case ">>":
+		if left.Sign() < 0 || right.Sign() < 0 {
+			return nil, fmt.Errorf("Bitwsise shift requires two unsigned Integers")
+		}
+		if !right.IsInt64() {
+			return &IntegerType{Value: 0}, nil
+		}
+		return newInteger(left.Rsh(left, uint(right.Int64()))), nil
+	case "&":
+		return newInteger(left.And(left, right)), nil
+	case "|":
+		return newInteger(left.Or(left, right)), nil
+	case "==":
+		return i.nativeToBoolean(left.Cmp(right) == 0), nil
+	case "!=":
+		return i.nativeToBoolean(left.Cmp(right) != 0), nil
+	case "..":
+		return nil, fmt.Errorf("Range operator doesn't support Integers this large")
+	default:
+		return nil, fmt.Errorf("Unsupported Integer operator '%s'", operator)
+	}
+}
+
 // Interpret infix operation for Floats.
 func (i *Interpreter) runFloatInfix(operator string, left, right float64) (DataType, error) {
 	switch operator {
@@ -1258,19 +1780,67 @@ func (i *Interpreter) runFloatInfix(operator string, left, right float64) (DataT
 	}
 }
 
+// Interpret infix operation for Decimals.
+func (i *Interpreter) runDecimalInfix(operator string, left, right decimal.Decimal) (DataType, error) {
+	switch operator {
+	case "+":
+		return &DecimalType{Value: left.Add(right)}, nil
+	case "-":
+		return &DecimalType{Value: left.Sub(right)}, nil
+	case "*":
+		return &DecimalType{Value: left.Mul(right)}, nil
+	case "/":
+		result, err := left.Quo(right)
+		if err != nil {
+			return nil, err
+		}
+		return &DecimalType{Value: result}, nil
+	case "%":
+		result, err := left.Mod(right)
+		if err != nil {
+			return nil, err
+		}
+		return &DecimalType{Value: result}, nil
+	case "**":
+		// Only whole exponents keep the result exact.
+		if !right.IsInteger() || !right.Int().IsInt64() {
+			return nil, fmt.Errorf("Decimal exponent must be a whole number")
+		}
+		result, err := left.Pow(right.Int().Int64())
+		if err != nil {
+			return nil, err
+		}
+		return &DecimalType{Value: result}, nil
+	case "<":
+		return i.nativeToBoolean(left.Cmp(right) < 0), nil
+	case "<=":
+		return i.nativeToBoolean(left.Cmp(right) <= 0), nil
+	case ">":
+		return i.nativeToBoolean(left.Cmp(right) > 0), nil
+	case ">=":
+		return i.nativeToBoolean(left.Cmp(right) >= 0), nil
+	case "==":
+		return i.nativeToBoolean(left.Cmp(right) == 0), nil
+	case "!=":
+		return i.nativeToBoolean(left.Cmp(right) != 0), nil
+	default:
+		return nil, fmt.Errorf("Unsupported Decimal operator '%s'", operator)
+	}
+}
+
 // Interpret infix operation for Strings.
 func (i *Interpreter) runStringInfix(operator string, left, right string) (DataType, error) {
 	switch operator {
 	case "+": // Concat two strings.
 		return &StringType{Value: left + right}, nil
 	case "<":
-		return i.nativeToBoolean(len(left) < len(right)), nil
+		return i.nativeToBoolean(i.stringLength(left) < i.stringLength(right)), nil
 	case "<=":
-		return i.nativeToBoolean(len(left) <= len(right)), nil
+		return i.nativeToBoolean(i.stringLength(left) <= i.stringLength(right)), nil
 	case ">":
-		return i.nativeToBoolean(len(left) > len(right)), nil
+		return i.nativeToBoolean(i.stringLength(left) > i.stringLength(right)), nil
 	case ">=":
-		return i.nativeToBoolean(len(left) >= len(right)), nil
+		return i.nativeToBoolean(i.stringLength(left) >= i.stringLength(right)), nil
 	case "==":
 		return i.nativeToBoolean(left == right), nil
 	case "!=":
@@ -1301,6 +1871,19 @@ func (i *Interpreter) runBooleanInfix(operator string, left, right DataType) (Da
 	}
 }
 
+// Interpret infix operation for Regexes, equal
+// when their patterns are.
+func (i *Interpreter) runRegexInfix(operator string, left, right *RegexType) (DataType, error) {
+	switch operator {
+	case "==":
+		return i.nativeToBoolean(left.Value.String() == right.Value.String()), nil
+	case "!=":
+		return i.nativeToBoolean(left.Value.String() != right.Value.String()), nil
+	default:
+		return nil, fmt.Errorf("Unsupported Regex operator '%s'", operator)
+	}
+}
+
 // Interpret infix operation for Arrays.
 func (i *Interpreter) runArrayInfix(operator string, left, right DataType) (DataType, error) {
 	leftVal := left.(*ArrayType).Elements
@@ -1324,29 +1907,121 @@ func (i *Interpreter) runArrayInfix(operator string, left, right DataType) (Data
 
 // Interpret infix operation for Dictionaries.
 func (i *Interpreter) runDictionaryInfix(operator string, left, right DataType) (DataType, error) {
-	leftVal := left.(*DictionaryType).Pairs
-	rightVal := right.(*DictionaryType).Pairs
+	leftVal := left.(*DictionaryType)
+	rightVal := right.(*DictionaryType)
 
 	switch operator {
 	case "+": // Combine two dictionaries.
-		// Add left keys to the right.
-		for k, v := range leftVal {
-			rightVal[k] = v
+		// Left keys come first and win over the
+		// same keys on the right.
+		result := NewDictionary()
+		for _, pair := range leftVal.Pairs {
+			result.Set(pair.Key, pair.Value)
+		}
+		for _, pair := range rightVal.Pairs {
+			if _, ok := result.Get(pair.Key); !ok {
+				result.Set(pair.Key, pair.Value)
+			}
 		}
-		return &DictionaryType{Pairs: rightVal}, nil
+		return result, nil
 	case "==":
 		return i.nativeToBoolean(i.compareDictionaries(leftVal, rightVal)), nil
 	case "!=":
 		return i.nativeToBoolean(!i.compareDictionaries(leftVal, rightVal)), nil
 	case "<":
-		return i.nativeToBoolean(len(leftVal) < len(rightVal)), nil
+		return i.nativeToBoolean(leftVal.Len() < rightVal.Len()), nil
 	case ">":
-		return i.nativeToBoolean(len(leftVal) > len(rightVal)), nil
+		return i.nativeToBoolean(leftVal.Len() > rightVal.Len()), nil
 	default:
 		return nil, fmt.Errorf("Unsupported Dictionary operator '%s'", operator)
 	}
 }
 
+// Interpret infix operation for Sets.
+func (i *Interpreter) runSetInfix(operator string, left, right *SetType) (DataType, error) {
+	switch operator {
+	case "|": // Union.
+		result := NewSet(left.Elements...)
+		for _, element := range right.Elements {
+			result.Add(element)
+		}
+		return result, nil
+	case "&": // Intersection.
+		result := NewSet()
+		for _, element := range left.Elements {
+			if right.Contains(element) {
+				result.Add(element)
+			}
+		}
+		return result, nil
+	case "-": // Difference.
+		result := NewSet()
+		for _, element := range left.Elements {
+			if !right.Contains(element) {
+				result.Add(element)
+			}
+		}
+		return result, nil
+	case "==":
+		return i.nativeToBoolean(i.compareSets(left, right)), nil
+	case "!=":
+		return i.nativeToBoolean(!i.compareSets(left, right)), nil
+	default:
+		return nil, fmt.Errorf("Unsupported Set operator '%s'", operator)
+	}
+}
+
+// Interpret the IN operator, checking if a value is an
+// element of a Set or Array, a key of a Dictionary or
+// part of a String.
+func (i *Interpreter) runInInfix(left, right DataType) (DataType, error) {
+	switch collection := right.(type) {
+	case *SetType:
+		return i.nativeToBoolean(collection.Contains(left)), nil
+	case *ArrayType:
+		for _, element := range collection.Elements {
+			if i.valuesEqual(element, left) {
+				return TRUE, nil
+			}
+		}
+		return FALSE, nil
+	case *DictionaryType:
+		return i.nativeToBoolean(i.containsKey(collection, left)), nil
+	case *StringType:
+		str, ok := left.(*StringType)
+		if !ok {
+			return nil, fmt.Errorf("Operator 'in' expects a String to find in a String but got '%s'", left.Type())
+		}
+		return i.nativeToBoolean(strings.Contains(collection.Value, str.Value)), nil
+	default:
+		return nil, fmt.Errorf("Operator 'in' expects a Set, Array, Dictionary or String but got '%s'", right.Type())
+	}
+}
+
+// Check if two values are equal as the == operator
+// sees them, so 1 equals 1.0 and :a equals "a". Values
+// that can't be compared aren't equal.
+func (i *Interpreter) valuesEqual(left, right DataType) bool {
+	equal, err := i.runOperator("==", left, right)
+	return err == nil && i.isTruthy(equal)
+}
+
+// Check if a dictionary has a key equal to the given
+// one. The exact key is looked up first.
+func (i *Interpreter) containsKey(dict *DictionaryType, key DataType) bool {
+	if _, found := dict.Get(key); found {
+		return true
+	}
+
+	for _, pair := range dict.Pairs {
+		if i.valuesEqual(pair.Key, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Interpret infix operation for Nil.
 func (i *Interpreter) runNilInfix(operator string, left, right DataType) (DataType, error) {
 	switch operator {
@@ -1386,17 +2061,22 @@ func (i *Interpreter) runRangeIntegerInfix(left, right int64) DataType {
 
 // Generate an array from two strings.
 func (i *Interpreter) runRangeStringInfix(left, right string) (DataType, error) {
-	if len(left) > 1 || len(right) > 1 {
+	if utf8.RuneCountInString(left) != 1 || utf8.RuneCountInString(right) != 1 {
 		return nil, fmt.Errorf("Range operator expects 2 single character strings")
 	}
 
 	result := []DataType{}
 	alphabet := "0123456789abcdefghijklmnopqrstuvwxyz"
-	// Only lowercase, single character strings are supported.
 	// Convert it to int32 for easy comparison in the loop.
 	leftByte := []int32(strings.ToLower(left))[0]
 	rightByte := []int32(strings.ToLower(right))[0]
 
+	// Characters outside the alphabet, like "α".."ω",
+	// are ranged by their code point.
+	if !strings.ContainsRune(alphabet, leftByte) || !strings.ContainsRune(alphabet, rightByte) {
+		return i.runRangeRuneInfix([]int32(left)[0], []int32(right)[0]), nil
+	}
+
 	if leftByte < rightByte {
 		// a -> z
 		for _, v := range alphabet {
@@ -1417,6 +2097,23 @@ func (i *Interpreter) runRangeStringInfix(left, right string) (DataType, error)
 	return &ArrayType{Elements: result}, nil
 }
 
+// Generate an array from two runes by their code point.
+func (i *Interpreter) runRangeRuneInfix(left, right rune) DataType {
+	result := []DataType{}
+
+	if left < right {
+		for r := left; r <= right; r++ {
+			result = append(result, &StringType{Value: string(r)})
+		}
+	} else {
+		for r := left; r >= right; r-- {
+			result = append(result, &StringType{Value: string(r)})
+		}
+	}
+
+	return &ArrayType{Elements: result}
+}
+
 // Check if it's an object that triggers an immediate
 // break of the block.
 func (i *Interpreter) shouldBreakImmediately(object DataType) bool {
@@ -1457,28 +2154,41 @@ func (i *Interpreter) compareArrays(left, right []DataType) bool {
 }
 
 // Check if two dictionaries are identical if all of their keys
-// are the same.
-func (i *Interpreter) compareDictionaries(left, right map[DataType]DataType) bool {
-	if len(left) != len(right) {
+// and values are the same. Order of the pairs is irrelevant.
+func (i *Interpreter) compareDictionaries(left, right *DictionaryType) bool {
+	if left.Len() != right.Len() {
 		return false
 	}
 
-	found := 0
-	// Both maps are iterated and for each found combination
-	// of the same key/value, 'found' is incremented.
-	// The maps are the same if 'found' equals the size of the
-	// left map. Although it works, I'm not quite fond of this
-	// solution.
-	for lk, lv := range left {
-		for rk, rv := range right {
-			if lk.Inspect() == rk.Inspect() && lv.Inspect() == rv.Inspect() {
-				found += 1
-				continue
-			}
+	for _, pair := range left.Pairs {
+		value, ok := right.Get(pair.Key)
+		if !ok {
+			return false
+		}
+
+		// Same type and same string representation.
+		if value.Type() != pair.Value.Type() || value.Inspect() != pair.Value.Inspect() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Check if two sets have the same elements, no
+// matter their order.
+func (i *Interpreter) compareSets(left, right *SetType) bool {
+	if left.Len() != right.Len() {
+		return false
+	}
+
+	for _, element := range left.Elements {
+		if !right.Contains(element) {
+			return false
 		}
 	}
 
-	return found == len(left)
+	return true
 }
 
 // Convert a StringType to ArrayType.
@@ -1486,13 +2196,24 @@ func (i *Interpreter) stringToArray(str *StringType) *ArrayType {
 	array := &ArrayType{}
 	array.Elements = []DataType{}
 
-	for _, s := range str.Value {
-		array.Elements = append(array.Elements, &StringType{Value: string(s)})
+	for _, s := range i.splitString(str.Value) {
+		array.Elements = append(array.Elements, &StringType{Value: s})
 	}
 
 	return array
 }
 
+// Convert an ArrayType of strings back to a StringType.
+func (i *Interpreter) arrayToString(elements []DataType) *StringType {
+	var out strings.Builder
+
+	for _, e := range elements {
+		out.WriteString(e.Inspect())
+	}
+
+	return &StringType{Value: out.String()}
+}
+
 // Convert a native Go boolean to a Boolean DataType.
 func (i *Interpreter) nativeToBoolean(value bool) DataType {
 	if value {
@@ -1518,10 +2239,14 @@ func (i *Interpreter) isTruthy(object DataType) bool {
 		return object.Value != 0
 	case *FloatType:
 		return object.Value != 0.0
+	case *DecimalType:
+		return object.Value.Sign() != 0
 	case *ArrayType:
 		return len(object.Elements) > 0
 	case *DictionaryType:
-		return len(object.Pairs) > 0
+		return object.Len() > 0
+	case *SetType:
+		return object.Len() > 0
 	default:
 		return false
 	}
@@ -1554,17 +2279,27 @@ func (i *Interpreter) checkArrayBounds(array []DataType, index int64) (int64, er
 	return index, nil
 }
 
-// Check if the index is within the string bounds.
-func (i *Interpreter) checkStringBounds(str string, index int64) (int64, error) {
+// Number of characters in a string.
+func (i *Interpreter) stringLength(str string) int {
+	if !i.graphemes {
+		return utf8.RuneCountInString(str)
+	}
+
+	return len(i.splitString(str))
+}
+
+// Check if the index is within the bounds of
+// the string characters.
+func (i *Interpreter) checkStringBounds(chars []string, index int64) (int64, error) {
 	originalIdx := index
 
 	// Handle negative index.
 	if index < 0 {
-		index = int64(len(str)) + index
+		index = int64(len(chars)) + index
 	}
 
 	// Check bounds.
-	if index < 0 || index > int64(len(str)-1) {
+	if index < 0 || index > int64(len(chars)-1) {
 		return 0, fmt.Errorf("String index '%d' out of bounds", originalIdx)
 	}
 
@@ -1574,8 +2309,8 @@ func (i *Interpreter) checkStringBounds(str string, index int64) (int64, error)
 // Check if a type is supported.
 func (i *Interpreter) checkSupportedType(t string) bool {
 	switch t {
-	case INTEGER_TYPE, FLOAT_TYPE, STRING_TYPE, ATOM_TYPE, BOOLEAN_TYPE,
-		ARRAY_TYPE, DICTIONARY_TYPE, FUNCTION_TYPE:
+	case INTEGER_TYPE, FLOAT_TYPE, DECIMAL_TYPE, STRING_TYPE, ATOM_TYPE,
+		REGEX_TYPE, BOOLEAN_TYPE, ARRAY_TYPE, DICTIONARY_TYPE, SET_TYPE, FUNCTION_TYPE:
 		return true
 	default:
 		return false
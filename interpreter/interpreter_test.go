@@ -1,11 +1,22 @@
 package interpreter
 
 import (
+	"bytes"
+	"fmt"
+	"github.com/fadion/aria/ast"
 	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/library"
 	"github.com/fadion/aria/parser"
 	"github.com/fadion/aria/reader"
 	"github.com/fadion/aria/reporter"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestInterpreterString(t *testing.T) {
@@ -104,7 +115,10 @@ func TestInterpreterBoolean(t *testing.T) {
 		{`"hello" == "world"`, false},
 		{`[1, 2] == [3, 4]`, false},
 		{`[1, 2] == [1, 2]`, true},
-		{`["a": "b", "c": "d"] == ["a": "b", "c": "d"]`, true},
+		{`["a" => "b", "c" => "d"] == ["a" => "b", "c" => "d"]`, true},
+		{`["a" => "b", "c" => "d"] == ["c" => "d", "a" => "b"]`, true},
+		{`["a" => "b", "c" => "d"] == ["a" => "d", "c" => "b"]`, false},
+		{`[1 => "a"] == ["1" => "a"]`, false},
 		{`true == !false`, true},
 		{`true && true`, true},
 		{`true && false`, false},
@@ -179,6 +193,146 @@ func TestInterpreterSwitch(t *testing.T) {
 	}
 }
 
+func TestInterpreterDictionary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`["c" => 3, "a" => 1, "b" => 2]`, "[c => 3, a => 1, b => 2]"},
+		{`var d = [:z => 1, :a => 2]
+d[:m] = 3
+d[:z] = 4
+d`, "[:z => 4, :a => 2, :m => 3]"},
+		{`for k, v in ["x" => 1, "y" => 2, "w" => 3] do k end`, "[x, y, w]"},
+		{`["b" => 1] + ["a" => 2, "b" => 3]`, "[b => 1, a => 2]"},
+		{`Dict.delete(["c" => 1, "a" => 2, "b" => 3], "a")`, "[c => 1, b => 3]"},
+		{`Dict.keys([3 => "c", 1 => "a", 2 => "b"])`, "[3, 1, 2]"},
+		{`Dict.values([3 => "c", 1 => "a", 2 => "b"])`, "[c, a, b]"},
+		{`[1 => "int", "1" => "string"]`, "[1 => int, 1 => string]"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterUnicodeString(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		graphemes bool
+	}{
+		{`"円500"[0]`, "円", false},
+		{`"円500"[-4]`, "円", false},
+		{`"héllo"[1]`, "é", false},
+		{`var s = "naïve"
+s[2] = "i"
+s`, "naive", false},
+		{`String.count("円500")`, "4", false},
+		{`String.reverse("日本語")`, "語本日", false},
+		{`String.slice("ünïcödé", 2, 3)`, "ïcö", false},
+		{`for c in "añb" do c end`, "[a, ñ, b]", false},
+		{`"α".."γ"`, "[α, β, γ]", false},
+		{`"ab" < "円円円"`, "true", false},
+		{"String.count(\"e\u0301\")", "2", false},
+		{"String.count(\"e\u0301\")", "1", true},
+		{"\"e\u0301x\"[0]", "e\u0301", true},
+		{`String.reverse("🇦🇱👍🏽")`, "👍🏽🇦🇱", true},
+	}
+
+	for _, test := range tests {
+		runner := New()
+		runner.SetGraphemes(test.graphemes)
+
+		value := inspectWith(t, runner, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2, 3, 4, 5][1..3]`, "[2, 3, 4]"},
+		{`[1, 2, 3, 4, 5][2..]`, "[3, 4, 5]"},
+		{`[1, 2, 3, 4, 5][..-2]`, "[1, 2, 3, 4]"},
+		{`[1, 2, 3, 4, 5][..]`, "[1, 2, 3, 4, 5]"},
+		{`[1, 2, 3, 4, 5][0..-1..2]`, "[1, 3, 5]"},
+		{`[1, 2, 3, 4, 5][-1..0..-1]`, "[5, 4, 3, 2, 1]"},
+		{`[1, 2, 3][1..10]`, "[2, 3]"},
+		{`[1, 2, 3][2..1]`, "[]"},
+		{`let n = 2
+[1, 2, 3, 4][n - 1..n + 1]`, "[2, 3, 4]"},
+		{`[1, 2, 3][1 == 1 ? 0 : 1]`, "1"},
+		{`"hello world"[6..]`, "world"},
+		{`"円500円"[1..-2]`, "500"},
+		{`"abc"[-1..0..-1]`, "cba"},
+		{`var xs = [1, 2, 3, 4]
+xs[0..1] = [9, 9]
+xs`, "[9, 9, 3, 4]"},
+		{`var xs = [1, 2, 3, 4]
+xs[1..2] = [7]
+xs`, "[1, 7, 4]"},
+		{`var xs = [1, 2, 3, 4]
+xs[..] = []
+xs`, "[]"},
+		{`var xs = [1, 2, 3, 4]
+xs[0..-1..2] = [0, 0]
+xs`, "[0, 2, 0, 4]"},
+		{`var s = "hello"
+s[0..0] = "J"
+s`, "Jello"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterBigInteger(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`2 ** 64`, "18446744073709551616"},
+		{`2 ** 64 - 2 ** 64`, "0"},
+		{`9223372036854775807 + 1`, "9223372036854775808"},
+		{`-9223372036854775807 - 2`, "-9223372036854775809"},
+		{`4611686018427387904 * 4`, "18446744073709551616"},
+		{`-(-9223372036854775807 - 1)`, "9223372036854775808"},
+		{`1 << 70`, "1180591620717411303424"},
+		{`(1 << 70) >> 69`, "2"},
+		{`123456789012345678901234567890`, "123456789012345678901234567890"},
+		{`0xffffffffffffffffff`, "4722366482869645213695"},
+		{`(2 ** 64) / 2 ** 32`, "4294967296"},
+		{`(2 ** 64) % 10`, "6"},
+		{`2 ** 64 > 2 ** 63`, "true"},
+		{`2 ** 64 == 18446744073709551616`, "true"},
+		{`Math.pow(3, 50)`, "717897987691852588770249"},
+		{`String(2 ** 100)`, "1267650600228229401496703205376"},
+		{`Int("99999999999999999999") + 1`, "100000000000000000000"},
+		{`(2 ** 64) is Int`, "true"},
+		{`Float(2 ** 64)`, "18446744073709551616.000000"},
+		{`Int(1e20)`, "100000000000000000000"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
 func testStringType(t *testing.T, tp DataType, expected string) bool {
 	result, ok := tp.(*StringType)
 	if !ok {
@@ -245,5 +399,833 @@ func checkForErrors(t *testing.T) {
 		for _, v := range reporter.GetErrors() {
 			t.Errorf(v)
 		}
+		// Don't let errors leak into the next test.
+		reporter.ClearErrors()
+	}
+}
+
+// Interpret the input and describe the result: the
+// value of a String and the inspected value otherwise.
+func inspectResult(t *testing.T, input string) string {
+	return inspectWith(t, New(), input)
+}
+
+func inspectWith(t *testing.T, runner *Interpreter, input string) string {
+	program := parser.New(lexer.New(reader.New([]byte(input)))).Parse()
+	actual := runner.Interpret(program, NewScope())
+	checkForErrors(t)
+
+	if str, ok := actual.(*StringType); ok {
+		return str.Value
+	} else if actual != nil {
+		return actual.Inspect()
+	}
+
+	return ""
+}
+
+// Interpret the input, unless it fails to parse, and
+// check that the first error has the message.
+func expectError(t *testing.T, input, message string) DataType {
+	var actual DataType
+	program := parser.New(lexer.New(reader.New([]byte(input)))).Parse()
+	if !reporter.HasErrors() {
+		actual = New().Interpret(program, NewScope())
+	}
+
+	errors := reporter.GetErrors()
+	reporter.ClearErrors()
+
+	if len(errors) == 0 || !strings.Contains(errors[0], message) {
+		t.Errorf("Expected %q for %s but got %v", message, input, errors)
+	}
+
+	return actual
+}
+
+func TestInterpreterDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`12.50d`, "12.50"},
+		{`0.1d + 0.2d`, "0.3"},
+		{`0.1d + 0.2d == 0.3d`, "true"},
+		{`12.50d + 1`, "13.50"},
+		{`2 - 0.75d`, "1.25"},
+		{`2.5d * 1.25d`, "3.125"},
+		{`1d / 3d`, "0.3333333333333333"},
+		{`10.00d / 4d`, "2.50"},
+		{`7.5d % 2`, "1.5"},
+		{`1.5d ** 2`, "2.25"},
+		{`-12.50d`, "-12.50"},
+		{`1.50d == 1.5d`, "true"},
+		{`1.5d < 2`, "true"},
+		{`1e3d`, "1000"},
+		{`Decimal.round(2.345d, 2, :halfEven)`, "2.34"},
+		{`Decimal.round(2.345d, 2, :halfUp)`, "2.35"},
+		{`Decimal.round(-2.5d, 0, :floor)`, "-3"},
+		{`Decimal.round(2.5d, 2, :down)`, "2.50"},
+		{`Decimal.scale(12.50d)`, "2"},
+		{`0.1 as Decimal`, "0.1"},
+		{`"3.14" as Decimal`, "3.14"},
+		{`12.99d as Int`, "12"},
+		{`12.50d is Decimal`, "true"},
+		{`Math.floor(-2.5d)`, "-3"},
+		{`Math.ceil(2.1d)`, "3"},
+		{`Math.abs(-3.3d)`, "3.3"},
+		{`Type.isNumber?(1d)`, "true"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterAssert(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Assert.equal([1, 2], [1, 2])`, "true"},
+		{`Assert.truthy(1)`, "true"},
+		{`Assert.raises(func () do 1 / 0 end)`, "true"},
+		{`runtime_raises(func () do 1 end)`, "false"},
+		{"let f = func (x) do x * 2 end\nvar out = []\nfor i in 1..3\n  out[] = i |> f()\nend\nout", "[2, 4, 6]"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterHook(t *testing.T) {
+	input := "let double = func (x)\n  x * 2\nend\nlet a = double(1)\nlet b = Enum.size([a])\nlet c = 3"
+
+	lex := lexer.New(reader.New([]byte(input)))
+	parse := parser.New(lex)
+	program := parse.Parse()
+	runner := New()
+	runner.SetFile("test.ari")
+
+	steps := []string{}
+	runner.SetHook(func(node ast.Statement, scope *Scope) bool {
+		frames := runner.Frames()
+		frame := frames[len(frames)-1]
+		steps = append(steps, fmt.Sprintf("%s %s:%d", frame.Name, frame.File, node.TokenLocation().Row))
+		return node.TokenLocation().Row < 5
+	})
+
+	runner.Interpret(program, NewScope())
+	checkForErrors(t)
+
+	expected := "main test.ari:1, main test.ari:4, double test.ari:2, main test.ari:5"
+	if actual := strings.Join(steps, ", "); actual != expected {
+		t.Errorf("Expected %q but got %q", expected, actual)
+	}
+
+	if len(runner.Frames()) != 1 {
+		t.Errorf("Expected only the main frame but got %v", runner.Frames())
+	}
+}
+
+func TestInterpreterOutput(t *testing.T) {
+	lex := lexer.New(reader.New([]byte("print(\"a\")\nprintln([1, 2])")))
+	parse := parser.New(lex)
+	program := parse.Parse()
+
+	var out bytes.Buffer
+	runner := New()
+	runner.SetOutput(&out)
+	runner.Interpret(program, NewScope())
+	checkForErrors(t)
+
+	if out.String() != "a[1, 2]\n" {
+		t.Errorf("Expected %q but got %q", "a[1, 2]\n", out.String())
+	}
+}
+
+func TestInterpreterFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aria")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Appending goes after the line break.
+	if err := ioutil.WriteFile(filepath.Join(dir, "lines.txt"), []byte("one\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`File.write("{dir}/a.txt", "one")`, "true"},
+		{`File.read("{dir}/a.txt")`, "one"},
+		{`File.append("{dir}/lines.txt", "two")`, "true"},
+		{`File.lines("{dir}/lines.txt")`, "[one, two]"},
+		{`File.exists?("{dir}/a.txt")`, "true"},
+		{`File.exists?("{dir}/none.txt")`, "false"},
+		{`File.stat("{dir}/lines.txt")[:size]`, "8"},
+		{`File.stat("{dir}")[:dir]`, "true"},
+		{`File.copy("{dir}/a.txt", "{dir}/b.txt")`, "true"},
+		{`File.rename("{dir}/b.txt", "{dir}/c.txt")`, "true"},
+		{`Dir.mkdir("{dir}/sub/deep")`, "true"},
+		{`Dir.list("{dir}")`, "[a.txt, c.txt, lines.txt, sub]"},
+		{`Dir.glob("{dir}/*.txt") |> Enum.size()`, "3"},
+		{`Dir.walk("{dir}/sub") |> Enum.size()`, "1"},
+		{`File.delete("{dir}/c.txt")`, "true"},
+		{`File.exists?("{dir}/c.txt")`, "false"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, strings.Replace(test.input, "{dir}", dir, -1))
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterFileErrors(t *testing.T) {
+	expectError(t, `File.read("/nope/missing.txt")`, "File.read() failed: open /nope/missing.txt: no such file or directory")
+}
+
+func TestInterpreterJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aria")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	documents := map[string]string{
+		"doc.json":  `{"name": "aria", "tags": ["a", "b"], "version": 1.5, "stars": 10, "meta": null, "ok": true}`,
+		"big.json":  `[123456789012345678901234567890, 1e3]`,
+		"bad.json":  `{"a" 1}`,
+		"tail.json": `[1] x`,
+	}
+	for name, contents := range documents {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`JSON.parse(File.read("{dir}/doc.json"))["name"]`, "aria"},
+		{`JSON.parse(File.read("{dir}/doc.json"))["tags"]`, "[a, b]"},
+		{`JSON.parse(File.read("{dir}/doc.json"))["version"]`, "1.500000"},
+		{`JSON.parse(File.read("{dir}/doc.json"))["stars"] + 1`, "11"},
+		{`JSON.parse(File.read("{dir}/doc.json"))["meta"] == nil`, "true"},
+		{`JSON.parse(File.read("{dir}/big.json"))[0]`, "123456789012345678901234567890"},
+		{`JSON.parse(File.read("{dir}/big.json"))[1]`, "1000.000000"},
+		{`JSON.stringify(JSON.parse(File.read("{dir}/doc.json")))`, `{"name":"aria","tags":["a","b"],"version":1.5,"stars":10,"meta":null,"ok":true}`},
+		{`JSON.stringify([:ok, nil, 2.0, 1, "x"])`, `["ok",null,2.0,1,"x"]`},
+		{`JSON.stringify([:a => 1, 2 => [true]])`, `{"a":1,"2":[true]}`},
+		{`JSON.stringify([:a => [1]], true)`, "{\n  \"a\": [\n    1\n  ]\n}"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, strings.Replace(test.input, "{dir}", dir, -1))
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`JSON.parse(File.read("{dir}/bad.json"))`, "JSON.parse() invalid character '1' after object key at byte 5"},
+		{`JSON.parse(File.read("{dir}/tail.json"))`, "JSON.parse() invalid character 'x' after top-level value at byte 4"},
+		{`JSON.parse("")`, "JSON.parse() unexpected end of input at byte 0"},
+		{`JSON.parse("[1, x")`, "JSON.parse() invalid character 'x' looking for beginning of value at byte 4"},
+		{`JSON.parse("[1,]")`, "JSON.parse() invalid character ']' looking for beginning of value at byte 3"},
+		{`JSON.parse("[1, 2")`, "JSON.parse() unexpected end of input at byte 5"},
+		{`JSON.stringify(10.0 ** 400)`, "JSON.stringify() can't encode the non-finite Float"},
+		{`JSON.stringify([1.5 => 1])`, "JSON.stringify() can't encode a Float key"},
+	}
+
+	for _, test := range errorTests {
+		expectError(t, strings.Replace(test.input, "{dir}", dir, -1), test.expected)
+	}
+}
+
+func TestInterpreterHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("X-Token", r.Header.Get("Token"))
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`HTTP.get("{url}/a")[:status]`, "202"},
+		{`HTTP.get("{url}/a")[:body]`, "GET /a "},
+		{`HTTP.get("{url}/a", [:Token => "secret"])[:headers]["X-Token"]`, "secret"},
+		{`HTTP.post("{url}/b", "data")[:body]`, "POST /b data"},
+		{`HTTP.request("DELETE", "{url}/c")[:body]`, "DELETE /c "},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, strings.Replace(test.input, "{url}", server.URL, -1))
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	input := fmt.Sprintf(`HTTP.get("%s/slow", [=>], 50)`, server.URL)
+	program := parser.New(lexer.New(reader.New([]byte(input)))).Parse()
+	New().Interpret(program, NewScope())
+
+	errors := reporter.GetErrors()
+	reporter.ClearErrors()
+
+	if len(errors) == 0 || !strings.Contains(errors[0], "HTTP.get() failed") || !strings.Contains(errors[0], "Timeout") {
+		t.Errorf("Expected a timeout but got %v", errors)
+	}
+}
+
+func TestInterpreterHTTPServer(t *testing.T) {
+	input := `let handler = func (request)
+  if request[:path] == "/created"
+    [:status => 201, :headers => ["X-Method" => request[:method]], :body => request[:body]]
+  else
+    "hello " + request[:query]["name"]
+  end
+end`
+
+	scope := NewScope()
+	runner := New()
+	runner.Interpret(parser.New(lexer.New(reader.New([]byte(input)))).Parse(), scope)
+	checkForErrors(t)
+
+	handler, _ := scope.Read("handler")
+	call := &ast.FunctionCall{
+		Function:  &ast.Identifier{Value: "runtime_http_serve"},
+		Arguments: &ast.ExpressionList{Elements: []ast.Expression{&ast.Integer{Value: 80}, &ast.Identifier{Value: "handler"}}},
+	}
+	server := httptest.NewServer(runner.httpHandler(call, handler, scope))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/greet?name=aria")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || string(body) != "hello aria" {
+		t.Errorf("Expected 200 and %q but got %d and %q", "hello aria", response.StatusCode, body)
+	}
+
+	response, err = http.Post(server.URL+"/created", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusCreated || string(body) != "payload" || response.Header.Get("X-Method") != "POST" {
+		t.Errorf("Expected 201, %q and the method header but got %d, %q and %v", "payload", response.StatusCode, body, response.Header)
+	}
+	checkForErrors(t)
+}
+
+func TestInterpreterOS(t *testing.T) {
+	os.Setenv("ARIA_TEST_NAME", "aria")
+	defer os.Unsetenv("ARIA_TEST_NAME")
+	defer os.Unsetenv("ARIA_TEST_SET")
+
+	cwd, _ := os.Getwd()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`OS.args()`, "[a, b c]"},
+		{`OS.env("ARIA_TEST_NAME")`, "aria"},
+		{`OS.env("ARIA_TEST_MISSING") == nil`, "true"},
+		{"OS.setEnv(\"ARIA_TEST_SET\", \"set\")\nOS.env(\"ARIA_TEST_SET\")", "set"},
+		{`OS.cwd()`, cwd},
+		{`OS.exec("go", ["version"])[:status]`, "0"},
+		{`OS.exec("go", ["nope"])[:status]`, "2"},
+		{`OS.exec("go", ["nope"])[:stdout]`, ""},
+	}
+
+	for _, test := range tests {
+		runner := New()
+		runner.SetArgs([]string{"a", "b c"})
+
+		value := inspectWith(t, runner, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+}
+
+func TestInterpreterOSExit(t *testing.T) {
+	var output bytes.Buffer
+	code := -1
+
+	program := parser.New(lexer.New(reader.New([]byte(`println("before")
+OS.exit(3)
+println("after")`)))).Parse()
+	runner := New()
+	runner.SetOutput(&output)
+	runner.SetExit(func(c int) { code = c })
+	runner.Interpret(program, NewScope())
+	checkForErrors(t)
+
+	if code != 3 || output.String() != "before\n" {
+		t.Errorf("Expected exit code 3 after printing %q but got %d and %q", "before\n", code, output.String())
+	}
+}
+
+func TestInterpreterOSDisabled(t *testing.T) {
+	for _, input := range []string{`OS.args()`, `OS.env("HOME")`, `OS.exec("go", ["version"])`, `OS.exit(1)`} {
+		program := parser.New(lexer.New(reader.New([]byte(input)))).Parse()
+		runner := New()
+		runner.SetOS(false)
+		runner.SetExit(func(int) { t.Errorf("Expected %s not to exit", input) })
+		runner.Interpret(program, NewScope())
+
+		errors := reporter.GetErrors()
+		reporter.ClearErrors()
+
+		if len(errors) == 0 || !strings.Contains(errors[0], "is disabled in this interpreter") {
+			t.Errorf("Expected %s to be disabled but got %v", input, errors)
+		}
+	}
+}
+
+func TestInterpreterTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Time.date(2024, 3, 30, 12, 0, 0, "UTC")`, "1711800000000"},
+		{`Time.format(Time.date(2024, 3, 30, 12, 0, 0, "Europe/Tirane"), Time.iso, "UTC")`, "2024-03-30T11:00:00Z"},
+		{`Time.format(Time.date(2024, 3, 30, 12, 0, 0, "UTC"), "Jan 2, 2006 15:04 MST", "Asia/Tokyo")`, "Mar 30, 2024 21:00 JST"},
+		{`Time.format(Time.date(2024, 3, 30, 12, 0, 0, "UTC") + 90 * Time.minute, "15:04", "UTC")`, "13:30"},
+		{`Time.format(Time.addDate(Time.date(2024, 3, 30, 12, 0, 0, "Europe/Tirane"), 0, 0, 1, "Europe/Tirane"), "2006-01-02 15:04 MST", "Europe/Tirane")`, "2024-03-31 12:00 CEST"},
+		{`Time.format(Time.addDate(Time.date(2024, 1, 31, 0, 0, 0, "UTC"), 1, 1, 0, "UTC"), "2006-01-02", "UTC")`, "2025-03-03"},
+		{`Time.parse("2024-01-02 10:00", "2006-01-02 15:04", "UTC")`, "1704189600000"},
+		{`Time.parse("2024-01-02T10:00:00+01:00", Time.iso, "UTC")`, "1704186000000"},
+		{`Time.parts(Time.date(2024, 3, 30, 12, 0, 0, "UTC"), "America/New_York")[:hour]`, "8"},
+		{`Time.parts(Time.date(2024, 3, 30, 12, 0, 0, "UTC"), "America/New_York")[:offset]`, "-14400"},
+		{`Time.parts(Time.date(2024, 3, 30, 12, 0, 0, "UTC"), "UTC")[:weekday]`, "6"},
+		{`Time.fromUnix(1704189600)`, "1704189600000"},
+		{`Time.date(3000, 1, 1, 0, 0, 0, "UTC")`, "32503680000000"},
+		{`Time.format(Time.date(3000, 1, 1, 0, 0, 0, "UTC"), Time.iso, "UTC")`, "3000-01-01T00:00:00Z"},
+		{`Time.date(1969, 12, 31, 23, 59, 59, "UTC")`, "-1000"},
+		{`Time.format(-1, "2006-01-02 15:04:05.000", "UTC")`, "1969-12-31 23:59:59.999"},
+		{`Time.format(Time.date(1600, 6, 1, 0, 0, 0, "UTC"), "2006-01-02", "UTC")`, "1600-06-01"},
+		{`Time.duration("1h30m")`, "5400000"},
+		{`Time.duration("250ms") + Time.second`, "1250"},
+		{`Time.measure(func () do Time.sleep(5) end) >= 5.0`, "true"},
+		{`Time.since(Time.now()) < Time.second`, "true"},
+		{`Time.unix() * 1000 <= Time.now()`, "true"},
+		{`Time.monotonic() < Time.monotonic()`, "true"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`Time.format(0, Time.iso, "Mars/Olympus")`, "Time.format() unknown time zone 'Mars/Olympus'"},
+		{`Time.parse("yesterday", "2006-01-02", "UTC")`, "Time.parse() parsing time"},
+		{`Time.duration("soon")`, "Time.duration() invalid duration 'soon'"},
+		{`Time.measure(func () do 1 + "a" end)`, ""},
+	}
+
+	for _, test := range errorTests {
+		if actual := expectError(t, test.input, test.expected); actual != nil {
+			t.Errorf("Expected no value for %s but got %s", test.input, actual.Inspect())
+		}
+	}
+}
+
+func TestInterpreterRegex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`~r"a+\d"`, `~r"a+\d"`},
+		{`~r"a+" is Regex`, "true"},
+		{`~r"a+" as String`, "a+"},
+		{`~r"a+" == Regex.new("a+")`, "true"},
+		{`~r"a+" != ~r"b+"`, "true"},
+		{`Regex.match?("abc", ~r"^a")`, "true"},
+		{`String.match?("abc", "b+")`, "true"},
+		{`String.match?("abc", "^b")`, "false"},
+		{`Regex.find("on 2024-03 and 2025-11", ~r"\d{4}")`, "2024"},
+		{`Regex.find("abc", ~r"\d") == nil`, "true"},
+		{`Regex.findAll("on 2024-03 and 2025-11", ~r"\d{4}")`, "[2024, 2025]"},
+		{`Regex.captures("on 2024-03", ~r"(\d{4})-(\d{2})")`, "[2024-03, 2024, 03]"},
+		{`Regex.captures("b", ~r"(a)?b")`, "[b, nil]"},
+		{`Regex.capturesAll("1-2 3-4", ~r"(\d)-(\d)")`, "[[1-2, 1, 2], [3-4, 3, 4]]"},
+		{`Regex.named("on 2024-03", ~r"(?P<year>\d{4})-(?P<month>\d{2})")["month"]`, "03"},
+		{`Regex.named("none", ~r"(?P<year>\d{4})") == nil`, "true"},
+		{`Regex.replace("2024-03", ~r"(?P<y>\d+)-(?P<m>\d+)", "${m}/${y}")`, "03/2024"},
+		{`Regex.replace("a1b22", ~r"\d+", (m) -> "<" + m + ">")`, "a<1>b<22>"},
+		{`"a, b,c" |> Regex.split(~r",\s*")`, "[a, b, c]"},
+		{`Regex.split("a,b,c", ~r",", 2)`, "[a, b,c]"},
+		{`~r"\"\w+\"" as String`, `"\w+"`},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`~r"(a"`, "Invalid regex: error parsing regexp: missing closing )"},
+		{`Regex.new("(a")`, "Regex.new() invalid regex"},
+		{`Regex.replace("a1", ~r"\d", (m) -> 1)`, "Regex.replace() expects the function to return a String but got 'Int'"},
+		{`Regex.replace("a1", ~r"\d", 1)`, "Regex.replace() expects the replacement as a String or Function"},
+		{`~r"a" + ~r"b"`, "Unsupported Regex operator '+'"},
+		{`String.match?("a", "(a")`, "runtime_regex_match() couldn't compile the regular expression"},
+	}
+
+	for _, test := range errorTests {
+		expectError(t, test.input, test.expected)
+	}
+}
+
+// String patterns are compiled once and reused.
+func TestInterpreterRegexCache(t *testing.T) {
+	input := `for word in ["ab", "cd", "ae"]
+  String.match?(word, "^a")
+end`
+
+	runner := New()
+	runner.Interpret(parser.New(lexer.New(reader.New([]byte(input)))).Parse(), NewScope())
+	checkForErrors(t)
+
+	if len(runner.regexes) != 1 || runner.regexes["^a"] == nil {
+		t.Errorf("Expected the pattern compiled once but got %v", runner.regexes)
+	}
+}
+
+func TestInterpreterEnum(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Enum.sort([3, 1.5, 2, 10])`, "[1.500000, 2, 3, 10]"},
+		{`Enum.sort(["pear", "apple", "fig"])`, "[apple, fig, pear]"},
+		{`Enum.sortBy(["ccc", "a", "bb", "d"], (s) -> String.count(s))`, "[a, d, bb, ccc]"},
+		{`Enum.groupBy([1, 2, 3, 4, 5], (x) -> x % 2 == 0)`, "[false => [1, 3, 5], true => [2, 4]]"},
+		{`Enum.countBy(["a", "b", "a"], (s) -> s)`, "[a => 2, b => 1]"},
+		{`Enum.partition([1, 2, 3, 4], (x) -> x > 2)`, "[[3, 4], [1, 2]]"},
+		{`Enum.zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`Enum.chunk([1, 2, 3, 4, 5], 2)`, "[[1, 2], [3, 4], [5]]"},
+		{`Enum.flatten([1, [2, [3, [4]]]])`, "[1, 2, 3, 4]"},
+		{`Enum.flatten([1, [2, [3, [4]]]], 1)`, "[1, 2, [3, [4]]]"},
+		{`Enum.take([1, 2, 3], 2)`, "[1, 2]"},
+		{`Enum.take([1, 2, 3], 5)`, "[1, 2, 3]"},
+		{`Enum.drop([1, 2, 3], 2)`, "[3]"},
+		{`Enum.takeWhile([1, 2, 3, 1], (x) -> x < 3)`, "[1, 2]"},
+		{`Enum.dropWhile([1, 2, 3, 1], (x) -> x < 3)`, "[3, 1]"},
+		{`Enum.any?([1, 2, 3], (x) -> x > 2)`, "true"},
+		{`Enum.all?([1, 2, 3], (x) -> x > 2)`, "false"},
+		{`Enum.all?([], (x) -> false)`, "true"},
+		{`Enum.sum([1, 2, 3])`, "6"},
+		{`Enum.sum([1, 2.5])`, "3.500000"},
+		{`Enum.sum([9223372036854775807, 1])`, "9223372036854775808"},
+		{`Enum.sum([])`, "0"},
+		{`Enum.min([3, 1, 2])`, "1"},
+		{`Enum.max(["b", "c", "a"])`, "c"},
+		{`Enum.minBy(["ccc", "a", "b"], (s) -> String.count(s))`, "a"},
+		{`Enum.maxBy(["a", "bb", "cc"], (s) -> String.count(s))`, "bb"},
+		{`Enum.maxBy([], (s) -> s) == nil`, "true"},
+		{`Enum.each([1, 2], (x) -> x * 2)`, "[1, 2]"},
+		{`Enum.withIndex(["a", "b"])`, "[[0, a], [1, b]]"},
+		{`Enum.range(1, 5)`, "[1, 2, 3, 4, 5]"},
+		{`Enum.range(10, 1, -3)`, "[10, 7, 4, 1]"},
+		{`Enum.range(100000, 1, -1) |> Enum.sort() |> Enum.take(3)`, "[1, 2, 3]"},
+		{`Enum.range(1, 100000) |> Enum.sortBy((x) -> -x) |> Enum.first()`, "100000"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`Enum.sort([1, "a"])`, "Enum.sort() can't compare"},
+		{`Enum.sum([1, "a"])`, "Enum.sum() expects numbers but got 'String'"},
+		{`Enum.chunk([1], 0)`, "Enum.chunk() expects a size bigger than 0"},
+		{`Enum.range(1, 5, 0)`, "Enum.range() expects a step other than 0"},
+		{`Enum.any?([1], (x) -> x + "a")`, "Cannot run expression"},
+	}
+
+	for _, test := range errorTests {
+		expectError(t, test.input, test.expected)
+	}
+}
+
+func TestInterpreterNativeMembers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Enum.size([1, 2, 3])`, "3"},
+		{`Enum.empty?([])`, "true"},
+		{`Dict.size([:a => 1, :b => 2])`, "2"},
+		{`Dict.contains?([:a => 1], :a)`, "true"},
+		{`Dict.contains?([1 => 1], "1")`, "false"},
+		{`Dict.contains?([:a => 1], "a")`, "true"},
+		{`Dict.contains?([1 => :a], 1.0)`, "true"},
+		{`Dict.contains?([1 => :a], 2.0)`, "false"},
+		{`Dict.contains?(["x" => 1, 2 => 1], 2)`, "true"},
+		{`String.count("héllo")`, "5"},
+		{`String.reverse("héllo")`, "olléh"},
+		{`String.reverse("")`, ""},
+		{`[1, 2] |> Enum.size()`, "2"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	// Arguments are still checked against the
+	// declaration and errors point at the call.
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"\nEnum.size(\"a\")", "[Line 2:10]: Function asks for type 'Array' but got 'String'"},
+		{"\nString.count()", "[Line 2:13]: Too few arguments in function call"},
+		{"\nDict.size([=>], 1)", "[Line 2:10]: Too many arguments in function call"},
+	}
+
+	for _, test := range errorTests {
+		expectError(t, test.input, test.expected)
+	}
+}
+
+// Native Standard Library members against the Aria
+// loops they replaced.
+func BenchmarkNativeMembers(b *testing.B) {
+	setup := `
+module Loops
+  let size = func (array: Array) -> Int
+    var count = 0
+    for v in array
+      count += 1
+    end
+    count
+  end
+
+  let dictSize = func (dict: Dictionary) -> Int
+    var count = 0
+    for v in dict
+      count += 1
+    end
+    count
+  end
+
+  let contains? = func (dict: Dictionary, key) -> Bool
+    for k, v in dict
+      if k == key
+        return true
+      end
+    end
+    false
+  end
+
+  let chars = func (str: String) -> Int
+    var cnt = 0
+    for v in str
+      cnt += 1
+    end
+    cnt
+  end
+
+  let reverse = func (str: String) -> String
+    var reversed = ""
+    for i in Loops.chars(str)-1..0
+      reversed += str[i]
+    end
+    reversed
+  end
+end
+
+let array = Enum.range(1, 1000)
+var dict = [=>]
+var str = ""
+for i in array
+  dict[i] = i
+  str += "a"
+end
+`
+
+	benchmarks := []struct {
+		name   string
+		native string
+		aria   string
+	}{
+		{"Enum.size", `Enum.size(array)`, `Loops.size(array)`},
+		{"Dict.size", `Dict.size(dict)`, `Loops.dictSize(dict)`},
+		{"Dict.contains?", `Dict.contains?(dict, 1000)`, `Loops.contains?(dict, 1000)`},
+		{"String.count", `String.count(str)`, `Loops.chars(str)`},
+		{"String.reverse", `String.reverse(str)`, `Loops.reverse(str)`},
+	}
+
+	run := func(b *testing.B, input string) {
+		runner := New()
+		scope := NewScope()
+		runner.Interpret(parser.New(lexer.New(reader.New([]byte(setup)))).Parse(), scope)
+		program := parser.New(lexer.New(reader.New([]byte(input)))).Parse()
+		if reporter.HasErrors() {
+			b.Fatal(reporter.GetErrors())
+		}
+
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			runner.Interpret(program, scope)
+		}
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name+"/native", func(b *testing.B) { run(b, benchmark.native) })
+		b.Run(benchmark.name+"/aria", func(b *testing.B) { run(b, benchmark.aria) })
+	}
+}
+
+func TestInterpreterLibraryLoading(t *testing.T) {
+	runner := New()
+	runner.Interpret(parser.New(lexer.New(reader.New([]byte(`1 + 1`)))).Parse(), NewScope())
+	checkForErrors(t)
+
+	if len(runner.modules) != 0 {
+		t.Errorf("Expected no modules loaded but got %d", len(runner.modules))
+	}
+
+	runner.Interpret(parser.New(lexer.New(reader.New([]byte(`Enum.empty?([])`)))).Parse(), NewScope())
+	checkForErrors(t)
+
+	if _, ok := runner.modules["Enum"]; !ok || len(runner.modules) != 1 {
+		t.Errorf("Expected only Enum loaded but got %d modules", len(runner.modules))
+	}
+
+	expectError(t, "module String\nend", "Module 'String' redeclared")
+
+	err := New().interpretLibraryModule(library.Module{Name: "Broken", File: "broken.ari", Source: []byte("module Broken\n  let x = \nend")})
+	reporter.ClearErrors()
+
+	if err == nil || !strings.HasPrefix(err.Error(), "Problem parsing Standard Library module broken.ari:") {
+		t.Errorf("Expected a parse error naming the file but got %v", err)
+	}
+}
+
+func TestInterpreterSet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#[1, 2, 2, 3]`, "#[1, 2, 3]"},
+		{`#[]`, "#[]"},
+		{`#[1, "1", :a]`, "#[1, 1, :a]"},
+		{`#[1, 2] is Set`, "true"},
+		{`!#[]`, "true"},
+		{`[1, 2] is Set`, "false"},
+		{`#[1, 2] | #[2, 3]`, "#[1, 2, 3]"},
+		{`#[1, 2, 3] & #[3, 2, 4]`, "#[2, 3]"},
+		{`#[1, 2, 3] - #[2]`, "#[1, 3]"},
+		{`#[1, 2] == #[2, 1]`, "true"},
+		{`#[1, 2] != #[1]`, "true"},
+		{`2 in #[1, 2]`, "true"},
+		{`"2" in #[1, 2]`, "false"},
+		{`2 in [1, 2]`, "true"},
+		{`:a in [:a => 1]`, "true"},
+		{`1 in [1.0]`, "true"},
+		{`:a in ["a" => 1]`, "true"},
+		{`1 in #[1.0]`, "false"},
+		{`"1" in [1, 2]`, "false"},
+		{`[1] in [[1], 2]`, "true"},
+		{`"ell" in "hello"`, "true"},
+		{`[3, 1, 3] as Set`, "#[3, 1]"},
+		{`#[3, 1] as Array`, "[3, 1]"},
+		{`Set([1, 1])`, "#[1]"},
+		{`var out = ""
+for v in #["a", "b", "a"]
+  out += v
+end
+out`, "ab"},
+		{`JSON.stringify(#[1, 2])`, "[1,2]"},
+		{`Set.new([1, 1, 2])`, "#[1, 2]"},
+		{`Set.size(#[1, 2])`, "2"},
+		{`Set.empty?(#[])`, "true"},
+		{`Set.contains?(#[1], 1)`, "true"},
+		{`Set.add(#[1], 2)`, "#[1, 2]"},
+		{`Set.delete(#[1, 2], 1)`, "#[2]"},
+		{`Set.union(#[1], #[2])`, "#[1, 2]"},
+		{`Set.intersection(#[1, 2], #[2])`, "#[2]"},
+		{`Set.difference(#[1, 2], #[2])`, "#[1]"},
+		{`Set.symmetricDifference(#[1, 2], #[2, 3])`, "#[1, 3]"},
+		{`Set.subset?(#[1], #[1, 2])`, "true"},
+		{`Set.superset?(#[1], #[1, 2])`, "false"},
+		{`Set.disjoint?(#[1], #[2])`, "true"},
+		{`Set.toArray(#[2, 1])`, "[2, 1]"},
+	}
+
+	for _, test := range tests {
+		value := inspectResult(t, test.input)
+		if value != test.expected {
+			t.Errorf("Expected %q for %s but got %q", test.expected, test.input, value)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`#[1] + #[2]`, "Unsupported Set operator '+'"},
+		{`1 in 2`, "Operator 'in' expects a Set, Array, Dictionary or String but got 'Int'"},
+		{`1 in "1"`, "Operator 'in' expects a String to find in a String but got 'Int'"},
+		{`#[1 => 2]`, "Set expects elements, not Key:Value pairs"},
+		{`Set.size([1])`, "Function asks for type 'Set' but got 'Array'"},
+	}
+
+	for _, test := range errorTests {
+		expectError(t, test.input, test.expected)
 	}
 }
@@ -0,0 +1,159 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"strings"
+)
+
+// Standard Library modules mix members written in Aria
+// with native ones written in Go. A native member is
+// declared in the module like any other, so it keeps its
+// signature and documentation, but its body only passes
+// the parameters to a runtime function:
+//
+//   let size = func (array: Array) -> Int
+//     runtime_enum_size(array)
+//   end
+//
+// Those members are bound to the runtime function when
+// the module is loaded, so calls check the arguments
+// against the declaration and run the Go code directly,
+// without interpreting the body.
+
+// nativeFunc is a runtime function that needs the
+// interpreter, like those depending on its settings.
+type nativeFunc func(i *Interpreter, args []DataType) (DataType, error)
+
+var natives = map[string]nativeFunc{
+
+	// runtime_string_count(String) -> Int
+	"runtime_string_count": func(i *Interpreter, args []DataType) (DataType, error) {
+		values, err := stringArguments("String.count", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &IntegerType{Value: int64(i.stringLength(values[0]))}, nil
+	},
+
+	// runtime_string_reverse(String) -> String
+	"runtime_string_reverse": func(i *Interpreter, args []DataType) (DataType, error) {
+		values, err := stringArguments("String.reverse", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		chars := i.splitString(values[0])
+		for left, right := 0, len(chars)-1; left < right; left, right = left+1, right-1 {
+			chars[left], chars[right] = chars[right], chars[left]
+		}
+
+		return &StringType{Value: strings.Join(chars, "")}, nil
+	},
+
+	// runtime_dict_contains(Dictionary, key Any) -> Bool
+	"runtime_dict_contains": func(i *Interpreter, args []DataType) (DataType, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("Dict.contains?() expects exactly 2 arguments")
+		}
+
+		dict, ok := args[0].(*DictionaryType)
+		if !ok {
+			return nil, fmt.Errorf("Dict.contains?() expects a Dictionary")
+		}
+
+		return i.nativeToBoolean(i.containsKey(dict, args[1])), nil
+	},
+
+	// runtime_regex_match(String, regex String) -> Bool
+	"runtime_regex_match": func(i *Interpreter, args []DataType) (DataType, error) {
+		values, err := stringArguments("runtime_regex_match", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		regex, err := i.compileRegex(values[1])
+		if err != nil {
+			return nil, fmt.Errorf("runtime_regex_match() couldn't compile the regular expression")
+		}
+
+		return i.nativeToBoolean(regex.Value.MatchString(values[0])), nil
+	},
+}
+
+// Run a native function.
+func (i *Interpreter) runNativeFunction(node *ast.FunctionCall, fn nativeFunc, scope *Scope) DataType {
+	args := []DataType{}
+	for _, element := range node.Arguments.Elements {
+		value := i.Interpret(element, scope)
+		if value == nil {
+			return nil
+		}
+		args = append(args, value)
+	}
+
+	object, err := fn(i, args)
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	return object
+}
+
+// Bind a module member to the runtime function its
+// body passes the parameters to, in the same order.
+// Anything else in the body and it stays in Aria.
+func (i *Interpreter) bindNative(function *FunctionType) {
+	if function.Variadic || len(function.Body.Statements) != 1 {
+		return
+	}
+
+	statement, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return
+	}
+
+	call, ok := statement.Expression.(*ast.FunctionCall)
+	if !ok || len(call.Arguments.Elements) != len(function.Parameters) {
+		return
+	}
+
+	name, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+
+	for index, argument := range call.Arguments.Elements {
+		parameter, ok := argument.(*ast.Identifier)
+		if !ok || parameter.Value != function.Parameters[index].Name.Value {
+			return
+		}
+	}
+
+	if rfn, ok := runtime[name.Value]; ok {
+		function.Native = func(args []DataType) (DataType, error) {
+			return rfn(args...)
+		}
+	} else if nfn, ok := natives[name.Value]; ok {
+		function.Native = func(args []DataType) (DataType, error) {
+			return nfn(i, args)
+		}
+	}
+}
+
+// Call the native function of a member with the
+// parameters already written to its scope.
+func (i *Interpreter) callNative(function *FunctionType, scope *Scope) (DataType, error) {
+	args := make([]DataType, len(function.Parameters))
+	for index, parameter := range function.Parameters {
+		value, ok := scope.Read(parameter.Name.Value)
+		if !ok {
+			return nil, fmt.Errorf("Missing argument '%s' in function call", parameter.Name.Value)
+		}
+		args[index] = value
+	}
+
+	return function.Native(args)
+}
@@ -3,9 +3,15 @@ package interpreter
 import (
 	"bufio"
 	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/decimal"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/token"
+	"math"
+	"math/big"
 	"math/rand"
 	"os"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,33 +19,27 @@ import (
 
 type runtimeFunc func(args ...DataType) (DataType, error)
 
-var runtime = map[string]runtimeFunc{
-
-	// println(Any)
-	"println": func(args ...DataType) (DataType, error) {
-		if len(args) != 1 {
-			return nil, fmt.Errorf("println() expects exactly 1 argument")
-		}
-
-		fmt.Println(args[0].Inspect())
-
-		// Return a dummy string just to suppress errors,
-		// as there's nothing to return.
-		return &StringType{Value: ""}, nil
-	},
-
-	// print(Any)
-	"print": func(args ...DataType) (DataType, error) {
-		if len(args) != 1 {
-			return nil, fmt.Errorf("print() expects exactly 1 argument")
-		}
-
-		fmt.Print(args[0].Inspect())
+type intrinsicFunc func(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType
+
+// RuntimeFunctions returns the names of the functions
+// provided by the runtime, in alphabetical order.
+func RuntimeFunctions() []string {
+	names := make([]string, 0, len(runtime)+len(intrinsics)+len(natives))
+	for name := range runtime {
+		names = append(names, name)
+	}
+	for name := range intrinsics {
+		names = append(names, name)
+	}
+	for name := range natives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
 
-		// Return a dummy string just to suppress errors,
-		// as there's nothing to return.
-		return &StringType{Value: ""}, nil
-	},
+var runtime = map[string]runtimeFunc{
 
 	// prompt(Any)
 	"prompt": func(args ...DataType) (DataType, error) {
@@ -81,13 +81,17 @@ var runtime = map[string]runtimeFunc{
 
 		switch object := args[0].(type) {
 		case *IntegerType:
-			return &StringType{Value: fmt.Sprintf("%d", object.Value)}, nil
+			return &StringType{Value: object.Inspect()}, nil
 		case *FloatType:
 			return &StringType{Value: fmt.Sprintf("%f", object.Value)}, nil
+		case *DecimalType:
+			return &StringType{Value: object.Inspect()}, nil
 		case *BooleanType:
 			return &StringType{Value: fmt.Sprintf("%t", object.Value)}, nil
 		case *StringType:
 			return object, nil
+		case *RegexType:
+			return &StringType{Value: object.Value.String()}, nil
 		default:
 			return nil, fmt.Errorf("String() can't convert '%s' to String", object.Type())
 		}
@@ -101,13 +105,22 @@ var runtime = map[string]runtimeFunc{
 
 		switch object := args[0].(type) {
 		case *StringType:
-			i, err := strconv.Atoi(object.Value)
-			if err != nil {
+			// Strings of any length are converted, as long
+			// as they're made of digits.
+			value, ok := new(big.Int).SetString(object.Value, 10)
+			if !ok {
 				return nil, fmt.Errorf("Int() can't convert '%s' to Integer", object.Value)
 			}
-			return &IntegerType{Value: int64(i)}, nil
+			return newInteger(value), nil
 		case *FloatType:
-			return &IntegerType{Value: int64(object.Value)}, nil
+			if math.IsNaN(object.Value) || math.IsInf(object.Value, 0) {
+				return nil, fmt.Errorf("Int() can't convert '%s' to Integer", object.Inspect())
+			}
+			value, _ := big.NewFloat(object.Value).Int(nil)
+			return newInteger(value), nil
+		case *DecimalType:
+			// Truncated towards zero, like Floats.
+			return newInteger(object.Value.Int()), nil
 		case *BooleanType:
 			result := 0
 			if object.Value {
@@ -136,7 +149,7 @@ var runtime = map[string]runtimeFunc{
 			}
 			return &FloatType{Value: i}, nil
 		case *IntegerType:
-			return &FloatType{Value: float64(object.Value)}, nil
+			return &FloatType{Value: object.FloatValue()}, nil
 		case *BooleanType:
 			result := 0
 			if object.Value {
@@ -145,11 +158,44 @@ var runtime = map[string]runtimeFunc{
 			return &FloatType{Value: float64(result)}, nil
 		case *FloatType:
 			return &FloatType{Value: object.Value}, nil
+		case *DecimalType:
+			return &FloatType{Value: object.Value.Float64()}, nil
 		default:
 			return nil, fmt.Errorf("Float() can't convert '%s' to Integer", object.Type())
 		}
 	},
 
+	// Decimal(Any) -> Decimal
+	"Decimal": func(args ...DataType) (DataType, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Decimal() expects exactly 1 argument")
+		}
+
+		switch object := args[0].(type) {
+		case *StringType:
+			value, err := decimal.Parse(object.Value)
+			if err != nil {
+				return nil, fmt.Errorf("Decimal() can't convert '%s' to Decimal", object.Value)
+			}
+			return &DecimalType{Value: value}, nil
+		case *IntegerType:
+			return &DecimalType{Value: decimal.FromInt(object.BigValue())}, nil
+		case *FloatType:
+			// Floats are converted from their shortest
+			// representation, so 0.1 becomes 0.1 and not
+			// its inexact binary value.
+			value, err := decimal.FromFloat(object.Value)
+			if err != nil {
+				return nil, fmt.Errorf("Decimal() can't convert '%s' to Decimal", object.Inspect())
+			}
+			return &DecimalType{Value: value}, nil
+		case *DecimalType:
+			return object, nil
+		default:
+			return nil, fmt.Errorf("Decimal() can't convert '%s' to Decimal", object.Type())
+		}
+	},
+
 	// Array(Any) -> Array
 	"Array": func(args ...DataType) (DataType, error) {
 		if len(args) != 1 {
@@ -159,11 +205,52 @@ var runtime = map[string]runtimeFunc{
 		switch object := args[0].(type) {
 		case *ArrayType:
 			return object, nil
+		case *SetType:
+			return &ArrayType{Elements: append([]DataType{}, object.Elements...)}, nil
 		default:
 			return &ArrayType{Elements: []DataType{object}}, nil
 		}
 	},
 
+	// Set(Any) -> Set
+	"Set": func(args ...DataType) (DataType, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Set() expects exactly 1 argument")
+		}
+
+		switch object := args[0].(type) {
+		case *SetType:
+			return object, nil
+		case *ArrayType:
+			return NewSet(object.Elements...), nil
+		default:
+			return NewSet(object), nil
+		}
+	},
+
+	// runtime_inspect(Any) -> String
+	"runtime_inspect": func(args ...DataType) (DataType, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("runtime_inspect() expects exactly 1 argument")
+		}
+
+		return &StringType{Value: args[0].Inspect()}, nil
+	},
+
+	// runtime_dict_size(Dictionary) -> Int
+	"runtime_dict_size": func(args ...DataType) (DataType, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Dict.size() expects exactly 1 argument")
+		}
+
+		dict, ok := args[0].(*DictionaryType)
+		if !ok {
+			return nil, fmt.Errorf("Dict.size() expects a Dictionary")
+		}
+
+		return &IntegerType{Value: int64(dict.Len())}, nil
+	},
+
 	// runtime_rand(min Integer, max Integer) -> Integer
 	"runtime_rand": func(args ...DataType) (DataType, error) {
 		if len(args) != 2 {
@@ -187,6 +274,50 @@ var runtime = map[string]runtimeFunc{
 		return &IntegerType{Value: int64(random)}, nil
 	},
 
+	// runtime_decimal_round(Decimal, places Integer, mode Atom) -> Decimal
+	"runtime_decimal_round": func(args ...DataType) (DataType, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("runtime_decimal_round() expects exactly 3 arguments")
+		}
+
+		if args[0].Type() != DECIMAL_TYPE {
+			return nil, fmt.Errorf("runtime_decimal_round() expects a Decimal")
+		}
+
+		if args[1].Type() != INTEGER_TYPE || args[1].(*IntegerType).IsBig() {
+			return nil, fmt.Errorf("runtime_decimal_round() expects places as an Integer")
+		}
+
+		if args[2].Type() != ATOM_TYPE {
+			return nil, fmt.Errorf("runtime_decimal_round() expects the rounding mode as an Atom")
+		}
+
+		places := args[1].(*IntegerType).Value
+		if places < math.MinInt32 || places > math.MaxInt32 {
+			return nil, fmt.Errorf("runtime_decimal_round() places '%d' out of range", places)
+		}
+
+		mode, ok := decimal.LookupRoundingMode(args[2].(*AtomType).Value)
+		if !ok {
+			return nil, fmt.Errorf("runtime_decimal_round() unknown rounding mode '%s'", args[2].Inspect())
+		}
+
+		return &DecimalType{Value: args[0].(*DecimalType).Value.Round(int32(places), mode)}, nil
+	},
+
+	// runtime_decimal_scale(Decimal) -> Integer
+	"runtime_decimal_scale": func(args ...DataType) (DataType, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("runtime_decimal_scale() expects exactly 1 argument")
+		}
+
+		if args[0].Type() != DECIMAL_TYPE {
+			return nil, fmt.Errorf("runtime_decimal_scale() expects a Decimal")
+		}
+
+		return &IntegerType{Value: int64(args[0].(*DecimalType).Value.Scale())}, nil
+	},
+
 	// runtime_tolower(String)
 	"runtime_tolower": func(args ...DataType) (DataType, error) {
 		if len(args) != 1 {
@@ -216,29 +347,128 @@ var runtime = map[string]runtimeFunc{
 
 		return &StringType{Value: strings.ToUpper(str)}, nil
 	},
+}
 
-	// runtime_regex_match(String, regex String) -> Bool
-	"runtime_regex_match": func(args ...DataType) (DataType, error) {
-		if len(args) != 2 {
-			return nil, fmt.Errorf("runtime_regex_match() expects exactly 2 arguments")
-		}
+// Runtime functions that need the interpreter, like those
+// calling back into Aria functions. They're set on init
+// because they refer back to the interpreter.
+var intrinsics map[string]intrinsicFunc
+
+func init() {
+	intrinsics = map[string]intrinsicFunc{
+		"println":        runtimePrintln,
+		"print":          runtimePrint,
+		"runtime_raises": runtimeRaises,
+
+		"runtime_http_serve": runtimeHTTPServe,
+
+		"runtime_os_args":    runtimeOSArgs,
+		"runtime_os_env":     runtimeOSEnv,
+		"runtime_os_set_env": runtimeOSSetEnv,
+		"runtime_os_cwd":     runtimeOSCwd,
+		"runtime_os_exit":    runtimeOSExit,
+		"runtime_os_exec":    runtimeOSExec,
+
+		"runtime_time_measure": runtimeTimeMeasure,
+
+		"runtime_regex_replace": runtimeRegexReplace,
+
+		"runtime_enum_each":       runtimeEnumEach,
+		"runtime_enum_sort_by":    runtimeEnumSortBy,
+		"runtime_enum_min_by":     runtimeEnumMinBy,
+		"runtime_enum_max_by":     runtimeEnumMaxBy,
+		"runtime_enum_group_by":   runtimeEnumGroupBy,
+		"runtime_enum_count_by":   runtimeEnumCountBy,
+		"runtime_enum_partition":  runtimeEnumPartition,
+		"runtime_enum_take_while": runtimeEnumTakeWhile,
+		"runtime_enum_drop_while": runtimeEnumDropWhile,
+		"runtime_enum_any":        runtimeEnumAny,
+		"runtime_enum_all":        runtimeEnumAll,
+	}
+}
 
-		if args[0].Type() != STRING_TYPE {
-			return nil, fmt.Errorf("runtime_regex_match() expects a String")
-		}
+// println(Any)
+// Written to the interpreter's output.
+func runtimePrintln(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	value := i.printArgument(node, scope, "println")
+	if value == nil {
+		return nil
+	}
 
-		if args[1].Type() != STRING_TYPE {
-			return nil, fmt.Errorf("runtime_regex_match() expects a String regex")
-		}
+	fmt.Fprintln(i.output, value.Inspect())
 
-		object := args[0].(*StringType).Value
-		match := args[1].(*StringType).Value
+	// Return a dummy string just to suppress errors,
+	// as there's nothing to return.
+	return &StringType{Value: ""}
+}
 
-		regx, err := regexp.Compile(match)
-		if err != nil {
-			return nil, fmt.Errorf("runtime_regex_match() couldn't compile the regular expression")
-		}
+// print(Any)
+func runtimePrint(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	value := i.printArgument(node, scope, "print")
+	if value == nil {
+		return nil
+	}
 
-		return &BooleanType{Value: regx.Find([]byte(object)) != nil}, nil
-	},
+	fmt.Fprint(i.output, value.Inspect())
+
+	return &StringType{Value: ""}
+}
+
+// The single argument of the print functions.
+func (i *Interpreter) printArgument(node *ast.FunctionCall, scope *Scope, name string) DataType {
+	if len(node.Arguments.Elements) != 1 {
+		i.reportError(node, fmt.Sprintf("%s() expects exactly 1 argument", name))
+		return nil
+	}
+
+	return i.Interpret(node.Arguments.Elements[0], scope)
+}
+
+// runtime_raises(Function) -> Bool
+// Call a function without arguments and check if it
+// fails. The failure is expected, so its errors are
+// dropped.
+func runtimeRaises(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	if len(node.Arguments.Elements) != 1 {
+		i.reportError(node, "runtime_raises() expects exactly 1 argument")
+		return nil
+	}
+
+	fn := i.Interpret(node.Arguments.Elements[0], scope)
+	if fn == nil {
+		return nil
+	}
+
+	if fn.Type() != FUNCTION_TYPE {
+		i.reportError(node, "runtime_raises() expects a Function")
+		return nil
+	}
+
+	count := reporter.CountErrors()
+	call := &ast.FunctionCall{Token: node.Token, Function: node.Arguments.Elements[0], Arguments: &ast.ExpressionList{}}
+	i.callFunction(call, fn, scope)
+
+	raised := reporter.CountErrors() > count
+	reporter.TruncateErrors(count)
+
+	return i.nativeToBoolean(raised)
+}
+
+// Call a function with values that are already
+// interpreted, as runtime functions do with callbacks.
+// The values are passed through a scope of their own.
+func (i *Interpreter) callWithValues(node *ast.FunctionCall, function ast.Expression, fn DataType, values []DataType, scope *Scope) DataType {
+	argscope := NewScopeFrom(scope)
+	arguments := &ast.ExpressionList{Token: node.Token}
+	for index, value := range values {
+		name := fmt.Sprintf("argument %d", index)
+		argscope.Write(name, value)
+		arguments.Elements = append(arguments.Elements, &ast.Identifier{
+			Token: token.Token{Type: token.IDENTIFIER, Lexeme: name, Location: node.Token.Location},
+			Value: name,
+		})
+	}
+
+	call := &ast.FunctionCall{Token: node.Token, Function: function, Arguments: arguments}
+	return i.callFunction(call, fn, argscope)
 }
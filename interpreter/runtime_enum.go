@@ -0,0 +1,670 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/decimal"
+	"github.com/fadion/aria/reporter"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Runtime functions behind the native members of the
+// Enum module. Sorting is stable and every function
+// keeps the order of the elements it returns.
+var enumRuntime = map[string]runtimeFunc{
+
+	// runtime_enum_size(Array) -> Int
+	"runtime_enum_size": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.size", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &IntegerType{Value: int64(len(array.Elements))}, nil
+	},
+
+	// runtime_enum_sort(Array) -> Array
+	"runtime_enum_sort": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.sort", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return sortValues("Enum.sort", array.Elements, array.Elements)
+	},
+
+	// runtime_enum_min(Array) -> Any
+	"runtime_enum_min": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.min", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return extremeValue("Enum.min", array.Elements, array.Elements, -1)
+	},
+
+	// runtime_enum_max(Array) -> Any
+	"runtime_enum_max": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.max", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return extremeValue("Enum.max", array.Elements, array.Elements, 1)
+	},
+
+	// runtime_enum_sum(Array) -> Int|Float|Decimal
+	"runtime_enum_sum": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.sum", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return sumValues(array.Elements)
+	},
+
+	// runtime_enum_zip(Array, Array) -> Array
+	"runtime_enum_zip": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.zip", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		other, ok := args[1].(*ArrayType)
+		if !ok {
+			return nil, fmt.Errorf("Enum.zip() expects an Array to zip with")
+		}
+
+		// As long as the shortest of the two.
+		pairs := []DataType{}
+		for index := 0; index < len(array.Elements) && index < len(other.Elements); index++ {
+			pairs = append(pairs, &ArrayType{Elements: []DataType{array.Elements[index], other.Elements[index]}})
+		}
+
+		return &ArrayType{Elements: pairs}, nil
+	},
+
+	// runtime_enum_chunk(Array, size Int) -> Array
+	"runtime_enum_chunk": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.chunk", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := countArgument("Enum.chunk", args[1])
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, fmt.Errorf("Enum.chunk() expects a size bigger than 0")
+		}
+
+		// The last chunk holds what's left.
+		chunks := []DataType{}
+		for start := 0; start < len(array.Elements); start += size {
+			end := start + size
+			if end > len(array.Elements) {
+				end = len(array.Elements)
+			}
+			chunks = append(chunks, &ArrayType{Elements: copyValues(array.Elements[start:end])})
+		}
+
+		return &ArrayType{Elements: chunks}, nil
+	},
+
+	// runtime_enum_flatten(Array, depth Int) -> Array
+	"runtime_enum_flatten": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.flatten", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		depth, ok := args[1].(*IntegerType)
+		if !ok {
+			return nil, fmt.Errorf("Enum.flatten() expects the depth as an Int")
+		}
+
+		return &ArrayType{Elements: flattenValues(array.Elements, depth.Value, []DataType{})}, nil
+	},
+
+	// runtime_enum_take(Array, count Int) -> Array
+	"runtime_enum_take": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.take", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		count, err := countArgument("Enum.take", args[1])
+		if err != nil {
+			return nil, err
+		}
+		if count > len(array.Elements) {
+			count = len(array.Elements)
+		}
+
+		return &ArrayType{Elements: copyValues(array.Elements[:count])}, nil
+	},
+
+	// runtime_enum_drop(Array, count Int) -> Array
+	"runtime_enum_drop": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.drop", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		count, err := countArgument("Enum.drop", args[1])
+		if err != nil {
+			return nil, err
+		}
+		if count > len(array.Elements) {
+			count = len(array.Elements)
+		}
+
+		return &ArrayType{Elements: copyValues(array.Elements[count:])}, nil
+	},
+
+	// runtime_enum_with_index(Array) -> Array
+	"runtime_enum_with_index": func(args ...DataType) (DataType, error) {
+		array, err := arrayArgument("Enum.withIndex", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		pairs := make([]DataType, len(array.Elements))
+		for index, element := range array.Elements {
+			pairs[index] = &ArrayType{Elements: []DataType{&IntegerType{Value: int64(index)}, element}}
+		}
+
+		return &ArrayType{Elements: pairs}, nil
+	},
+
+	// runtime_enum_range(from Int, to Int, step Int) -> Array
+	"runtime_enum_range": func(args ...DataType) (DataType, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("Enum.range() expects exactly 3 arguments")
+		}
+
+		values, err := intArguments("Enum.range", args)
+		if err != nil {
+			return nil, err
+		}
+
+		from, to, step := values[0], values[1], values[2]
+		if step == 0 {
+			return nil, fmt.Errorf("Enum.range() expects a step other than 0")
+		}
+
+		// Both ends are included, as in the range
+		// operator, and a negative step counts down.
+		elements := []DataType{}
+		for value := from; (step > 0 && value <= to) || (step < 0 && value >= to); value += step {
+			elements = append(elements, &IntegerType{Value: int64(value)})
+		}
+
+		return &ArrayType{Elements: elements}, nil
+	},
+}
+
+func init() {
+	for name, fn := range enumRuntime {
+		runtime[name] = fn
+	}
+}
+
+// runtime_enum_each(Array, Function) -> Array
+// Calls the function with every element, returning
+// the array.
+func runtimeEnumEach(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	array, fn, ok := i.enumArguments(node, scope, "Enum.each")
+	if !ok {
+		return nil
+	}
+
+	for _, element := range array.Elements {
+		if _, ok := i.enumCall(node, fn, element, scope); !ok {
+			return nil
+		}
+	}
+
+	return array
+}
+
+// runtime_enum_sort_by(Array, Function) -> Array
+// The function is called once per element and the
+// elements sorted by what it returns.
+func runtimeEnumSortBy(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	array, keys, ok := i.enumKeys(node, scope, "Enum.sortBy")
+	if !ok {
+		return nil
+	}
+
+	sorted, err := sortValues("Enum.sortBy", array.Elements, keys)
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	return sorted
+}
+
+// runtime_enum_min_by(Array, Function) -> Any
+func runtimeEnumMinBy(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	return i.enumExtreme(node, scope, "Enum.minBy", -1)
+}
+
+// runtime_enum_max_by(Array, Function) -> Any
+func runtimeEnumMaxBy(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	return i.enumExtreme(node, scope, "Enum.maxBy", 1)
+}
+
+func (i *Interpreter) enumExtreme(node *ast.FunctionCall, scope *Scope, name string, direction int) DataType {
+	array, keys, ok := i.enumKeys(node, scope, name)
+	if !ok {
+		return nil
+	}
+
+	extreme, err := extremeValue(name, array.Elements, keys, direction)
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	return extreme
+}
+
+// runtime_enum_group_by(Array, Function) -> Dictionary
+// Keys are in the order they're first returned.
+func runtimeEnumGroupBy(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	array, keys, ok := i.enumKeys(node, scope, "Enum.groupBy")
+	if !ok {
+		return nil
+	}
+
+	groups := NewDictionary()
+	for index, key := range keys {
+		group, ok := groups.Get(key)
+		if !ok {
+			group = &ArrayType{Elements: []DataType{}}
+			groups.Set(key, group)
+		}
+		group.(*ArrayType).Elements = append(group.(*ArrayType).Elements, array.Elements[index])
+	}
+
+	return groups
+}
+
+// runtime_enum_count_by(Array, Function) -> Dictionary
+func runtimeEnumCountBy(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	_, keys, ok := i.enumKeys(node, scope, "Enum.countBy")
+	if !ok {
+		return nil
+	}
+
+	counts := NewDictionary()
+	for _, key := range keys {
+		count, ok := counts.Get(key)
+		if !ok {
+			count = &IntegerType{Value: 0}
+		}
+		counts.Set(key, &IntegerType{Value: count.(*IntegerType).Value + 1})
+	}
+
+	return counts
+}
+
+// runtime_enum_partition(Array, Function) -> Array
+// The elements the function returns a truthy value for,
+// followed by the rest.
+func runtimeEnumPartition(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	array, keys, ok := i.enumKeys(node, scope, "Enum.partition")
+	if !ok {
+		return nil
+	}
+
+	matching, rest := []DataType{}, []DataType{}
+	for index, key := range keys {
+		if i.isTruthy(key) {
+			matching = append(matching, array.Elements[index])
+		} else {
+			rest = append(rest, array.Elements[index])
+		}
+	}
+
+	return &ArrayType{Elements: []DataType{&ArrayType{Elements: matching}, &ArrayType{Elements: rest}}}
+}
+
+// runtime_enum_take_while(Array, Function) -> Array
+func runtimeEnumTakeWhile(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	array, index, ok := i.enumWhile(node, scope, "Enum.takeWhile")
+	if !ok {
+		return nil
+	}
+
+	return &ArrayType{Elements: copyValues(array.Elements[:index])}
+}
+
+// runtime_enum_drop_while(Array, Function) -> Array
+func runtimeEnumDropWhile(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	array, index, ok := i.enumWhile(node, scope, "Enum.dropWhile")
+	if !ok {
+		return nil
+	}
+
+	return &ArrayType{Elements: copyValues(array.Elements[index:])}
+}
+
+// Index of the first element the function returns a
+// falsy value for, without calling it any further.
+func (i *Interpreter) enumWhile(node *ast.FunctionCall, scope *Scope, name string) (*ArrayType, int, bool) {
+	array, fn, ok := i.enumArguments(node, scope, name)
+	if !ok {
+		return nil, 0, false
+	}
+
+	for index, element := range array.Elements {
+		value, ok := i.enumCall(node, fn, element, scope)
+		if !ok {
+			return nil, 0, false
+		}
+		if !i.isTruthy(value) {
+			return array, index, true
+		}
+	}
+
+	return array, len(array.Elements), true
+}
+
+// runtime_enum_any(Array, Function) -> Bool
+// Stops at the first truthy value.
+func runtimeEnumAny(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	return i.enumCheck(node, scope, "Enum.any?", true)
+}
+
+// runtime_enum_all(Array, Function) -> Bool
+// Stops at the first falsy value.
+func runtimeEnumAll(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	return i.enumCheck(node, scope, "Enum.all?", false)
+}
+
+func (i *Interpreter) enumCheck(node *ast.FunctionCall, scope *Scope, name string, stopAt bool) DataType {
+	array, fn, ok := i.enumArguments(node, scope, name)
+	if !ok {
+		return nil
+	}
+
+	for _, element := range array.Elements {
+		value, ok := i.enumCall(node, fn, element, scope)
+		if !ok {
+			return nil
+		}
+		if i.isTruthy(value) == stopAt {
+			return i.nativeToBoolean(stopAt)
+		}
+	}
+
+	return i.nativeToBoolean(!stopAt)
+}
+
+// Interpret the Array and Function arguments of an
+// Enum function calling back into Aria.
+func (i *Interpreter) enumArguments(node *ast.FunctionCall, scope *Scope, name string) (*ArrayType, *FunctionType, bool) {
+	if len(node.Arguments.Elements) != 2 {
+		i.reportError(node, fmt.Sprintf("%s() expects exactly 2 arguments", name))
+		return nil, nil, false
+	}
+
+	array, ok := i.Interpret(node.Arguments.Elements[0], scope).(*ArrayType)
+	if !ok {
+		i.reportError(node, fmt.Sprintf("%s() expects an Array", name))
+		return nil, nil, false
+	}
+
+	fn, ok := i.Interpret(node.Arguments.Elements[1], scope).(*FunctionType)
+	if !ok {
+		i.reportError(node, fmt.Sprintf("%s() expects a Function", name))
+		return nil, nil, false
+	}
+
+	return array, fn, true
+}
+
+// Call the function of an Enum function with an
+// element, reporting false if it failed.
+func (i *Interpreter) enumCall(node *ast.FunctionCall, fn *FunctionType, element DataType, scope *Scope) (DataType, bool) {
+	count := reporter.CountErrors()
+	value := i.callWithValues(node, node.Arguments.Elements[1], fn, []DataType{element}, scope)
+	if reporter.CountErrors() > count {
+		return nil, false
+	}
+
+	if value == nil {
+		value = NIL
+	}
+
+	return value, true
+}
+
+// The values the function returns for every element.
+func (i *Interpreter) enumKeys(node *ast.FunctionCall, scope *Scope, name string) (*ArrayType, []DataType, bool) {
+	array, fn, ok := i.enumArguments(node, scope, name)
+	if !ok {
+		return nil, nil, false
+	}
+
+	keys := make([]DataType, len(array.Elements))
+	for index, element := range array.Elements {
+		if keys[index], ok = i.enumCall(node, fn, element, scope); !ok {
+			return nil, nil, false
+		}
+	}
+
+	return array, keys, true
+}
+
+// Check the arguments of a runtime function
+// taking an Array first.
+func arrayArgument(name string, args []DataType, count int) (*ArrayType, error) {
+	if err := argumentCount(name, len(args), count); err != nil {
+		return nil, err
+	}
+
+	array, ok := args[0].(*ArrayType)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects an Array but got '%s'", name, args[0].Type())
+	}
+
+	return array, nil
+}
+
+// A count of elements, which can't be negative.
+func countArgument(name string, arg DataType) (int, error) {
+	count, ok := arg.(*IntegerType)
+	if !ok || count.Big != nil || count.Value < 0 {
+		return 0, fmt.Errorf("%s() expects a count as an Int of 0 or more", name)
+	}
+
+	return int(count.Value), nil
+}
+
+func copyValues(values []DataType) []DataType {
+	return append([]DataType{}, values...)
+}
+
+// Sort values by their keys, which can be the values
+// themselves. Equal keys keep their order.
+func sortValues(name string, values, keys []DataType) (DataType, error) {
+	indexes := make([]int, len(values))
+	for index := range indexes {
+		indexes[index] = index
+	}
+
+	var err error
+	sort.SliceStable(indexes, func(a, b int) bool {
+		order, ok := compareValues(keys[indexes[a]], keys[indexes[b]])
+		if !ok && err == nil {
+			err = fmt.Errorf("%s() can't compare '%s' with '%s'", name, keys[indexes[a]].Type(), keys[indexes[b]].Type())
+		}
+		return order < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]DataType, len(values))
+	for index, original := range indexes {
+		sorted[index] = values[original]
+	}
+
+	return &ArrayType{Elements: sorted}, nil
+}
+
+// The value with the lowest key for a direction of
+// -1 or the highest for 1, the first of equal ones.
+// Nil for no values.
+func extremeValue(name string, values, keys []DataType, direction int) (DataType, error) {
+	if len(values) == 0 {
+		return NIL, nil
+	}
+
+	extreme := 0
+	for index := 1; index < len(keys); index++ {
+		order, ok := compareValues(keys[index], keys[extreme])
+		if !ok {
+			return nil, fmt.Errorf("%s() can't compare '%s' with '%s'", name, keys[index].Type(), keys[extreme].Type())
+		}
+		if order == direction {
+			extreme = index
+		}
+	}
+
+	return values[extreme], nil
+}
+
+// Order two values as the comparison operators do:
+// numbers by value, even of different types, and
+// Strings or Atoms alphabetically. Reports false for
+// values that can't be compared.
+func compareValues(left, right DataType) (int, bool) {
+	switch left := left.(type) {
+	case *IntegerType:
+		switch right := right.(type) {
+		case *IntegerType:
+			if left.Big == nil && right.Big == nil {
+				return compareInt64(left.Value, right.Value), true
+			}
+			return left.BigValue().Cmp(right.BigValue()), true
+		case *FloatType:
+			return compareFloat64(left.FloatValue(), right.Value), true
+		case *DecimalType:
+			return decimal.FromInt(left.BigValue()).Cmp(right.Value), true
+		}
+	case *FloatType:
+		switch right := right.(type) {
+		case *FloatType:
+			return compareFloat64(left.Value, right.Value), true
+		case *IntegerType:
+			return compareFloat64(left.Value, right.FloatValue()), true
+		}
+	case *DecimalType:
+		switch right := right.(type) {
+		case *DecimalType:
+			return left.Value.Cmp(right.Value), true
+		case *IntegerType:
+			return left.Value.Cmp(decimal.FromInt(right.BigValue())), true
+		}
+	case *StringType:
+		switch right := right.(type) {
+		case *StringType:
+			return strings.Compare(left.Value, right.Value), true
+		case *AtomType:
+			return strings.Compare(left.Value, right.Value), true
+		}
+	case *AtomType:
+		switch right := right.(type) {
+		case *AtomType:
+			return strings.Compare(left.Value, right.Value), true
+		case *StringType:
+			return strings.Compare(left.Value, right.Value), true
+		}
+	}
+
+	return 0, false
+}
+
+func compareInt64(left, right int64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+
+	return 0
+}
+
+func compareFloat64(left, right float64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+
+	return 0
+}
+
+// Add up numbers. Ints stay exact, Floats make a
+// Float and Decimals a Decimal, but Floats and Decimals
+// don't mix, as with the plus operator.
+func sumValues(values []DataType) (DataType, error) {
+	integer := new(big.Int)
+	float, floats := 0.0, false
+	dec, decimals := decimal.FromInt(new(big.Int)), false
+
+	for _, value := range values {
+		switch value := value.(type) {
+		case *IntegerType:
+			if value.Big != nil {
+				integer.Add(integer, value.Big)
+			} else {
+				integer.Add(integer, big.NewInt(value.Value))
+			}
+		case *FloatType:
+			float += value.Value
+			floats = true
+		case *DecimalType:
+			dec = dec.Add(value.Value)
+			decimals = true
+		default:
+			return nil, fmt.Errorf("Enum.sum() expects numbers but got '%s'", value.Type())
+		}
+	}
+
+	switch {
+	case floats && decimals:
+		return nil, fmt.Errorf("Enum.sum() can't add Floats and Decimals")
+	case floats:
+		f, _ := new(big.Float).SetInt(integer).Float64()
+		return &FloatType{Value: float + f}, nil
+	case decimals:
+		return &DecimalType{Value: dec.Add(decimal.FromInt(integer))}, nil
+	}
+
+	return newInteger(integer), nil
+}
+
+// Flatten nested arrays down to a depth, or all of
+// them for a negative depth.
+func flattenValues(values []DataType, depth int64, out []DataType) []DataType {
+	for _, value := range values {
+		if array, ok := value.(*ArrayType); ok && depth != 0 {
+			out = flattenValues(array.Elements, depth-1, out)
+			continue
+		}
+		out = append(out, value)
+	}
+
+	return out
+}
@@ -0,0 +1,318 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Runtime functions behind the File and Dir modules.
+// Errors carry the text of the OS error.
+var fileRuntime = map[string]runtimeFunc{
+
+	// runtime_file_read(path String) -> String
+	"runtime_file_read": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.read", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadFile(paths[0])
+		if err != nil {
+			return nil, osError("File.read", err)
+		}
+
+		return &StringType{Value: string(contents)}, nil
+	},
+
+	// runtime_file_write(path String, contents String) -> Bool
+	"runtime_file_write": func(args ...DataType) (DataType, error) {
+		values, err := stringArguments("File.write", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ioutil.WriteFile(values[0], []byte(values[1]), 0644); err != nil {
+			return nil, osError("File.write", err)
+		}
+
+		return &BooleanType{Value: true}, nil
+	},
+
+	// runtime_file_append(path String, contents String) -> Bool
+	"runtime_file_append": func(args ...DataType) (DataType, error) {
+		values, err := stringArguments("File.append", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := os.OpenFile(values[0], os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, osError("File.append", err)
+		}
+		defer file.Close()
+
+		if _, err := file.WriteString(values[1]); err != nil {
+			return nil, osError("File.append", err)
+		}
+
+		return &BooleanType{Value: true}, nil
+	},
+
+	// runtime_file_exists(path String) -> Bool
+	"runtime_file_exists": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.exists?", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = os.Stat(paths[0])
+		return &BooleanType{Value: err == nil}, nil
+	},
+
+	// runtime_file_lines(path String) -> Array
+	"runtime_file_lines": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.lines", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadFile(paths[0])
+		if err != nil {
+			return nil, osError("File.lines", err)
+		}
+
+		// A final line break doesn't start another line.
+		lines := []DataType{}
+		text := strings.TrimSuffix(string(contents), "\n")
+		if text != "" {
+			for _, line := range strings.Split(text, "\n") {
+				lines = append(lines, &StringType{Value: strings.TrimSuffix(line, "\r")})
+			}
+		}
+
+		return &ArrayType{Elements: lines}, nil
+	},
+
+	// runtime_file_stat(path String) -> Dictionary
+	"runtime_file_stat": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.stat", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(paths[0])
+		if err != nil {
+			return nil, osError("File.stat", err)
+		}
+
+		stat := NewDictionary()
+		stat.Set(&AtomType{Value: "name"}, &StringType{Value: info.Name()})
+		stat.Set(&AtomType{Value: "size"}, &IntegerType{Value: info.Size()})
+		stat.Set(&AtomType{Value: "mode"}, &StringType{Value: info.Mode().String()})
+		stat.Set(&AtomType{Value: "modified"}, &IntegerType{Value: info.ModTime().Unix()})
+		stat.Set(&AtomType{Value: "dir"}, &BooleanType{Value: info.IsDir()})
+
+		return stat, nil
+	},
+
+	// runtime_file_delete(path String) -> Bool
+	"runtime_file_delete": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.delete", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Remove(paths[0]); err != nil {
+			return nil, osError("File.delete", err)
+		}
+
+		return &BooleanType{Value: true}, nil
+	},
+
+	// runtime_file_copy(from String, to String) -> Bool
+	"runtime_file_copy": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.copy", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := copyFile(paths[0], paths[1]); err != nil {
+			return nil, osError("File.copy", err)
+		}
+
+		return &BooleanType{Value: true}, nil
+	},
+
+	// runtime_file_rename(from String, to String) -> Bool
+	"runtime_file_rename": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("File.rename", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Rename(paths[0], paths[1]); err != nil {
+			return nil, osError("File.rename", err)
+		}
+
+		return &BooleanType{Value: true}, nil
+	},
+
+	// runtime_dir_list(path String) -> Array
+	"runtime_dir_list": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("Dir.list", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := ioutil.ReadDir(paths[0])
+		if err != nil {
+			return nil, osError("Dir.list", err)
+		}
+
+		names := []DataType{}
+		for _, entry := range entries {
+			names = append(names, &StringType{Value: entry.Name()})
+		}
+
+		return &ArrayType{Elements: names}, nil
+	},
+
+	// runtime_dir_glob(pattern String) -> Array
+	"runtime_dir_glob": func(args ...DataType) (DataType, error) {
+		patterns, err := stringArguments("Dir.glob", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := filepath.Glob(patterns[0])
+		if err != nil {
+			return nil, fmt.Errorf("Dir.glob() invalid pattern '%s'", patterns[0])
+		}
+		sort.Strings(matches)
+
+		return stringArray(matches), nil
+	},
+
+	// runtime_dir_mkdir(path String) -> Bool
+	"runtime_dir_mkdir": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("Dir.mkdir", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.MkdirAll(paths[0], 0755); err != nil {
+			return nil, osError("Dir.mkdir", err)
+		}
+
+		return &BooleanType{Value: true}, nil
+	},
+
+	// runtime_dir_walk(path String) -> Array
+	"runtime_dir_walk": func(args ...DataType) (DataType, error) {
+		paths, err := stringArguments("Dir.walk", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		walked := []string{}
+		err = filepath.Walk(paths[0], func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != paths[0] {
+				walked = append(walked, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, osError("Dir.walk", err)
+		}
+
+		return stringArray(walked), nil
+	},
+}
+
+func init() {
+	for name, fn := range fileRuntime {
+		runtime[name] = fn
+	}
+}
+
+// Check the number of arguments of a runtime
+// function, all expected to be Strings.
+func stringArguments(name string, args []DataType, count int) ([]string, error) {
+	if err := argumentCount(name, len(args), count); err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	for _, arg := range args {
+		str, ok := arg.(*StringType)
+		if !ok {
+			return nil, fmt.Errorf("%s() expects String arguments but got '%s'", name, arg.Type())
+		}
+		values = append(values, str.Value)
+	}
+
+	return values, nil
+}
+
+// Check that a runtime function got as many arguments
+// as it expects.
+func argumentCount(name string, got, want int) error {
+	if got == want {
+		return nil
+	}
+
+	plural := "s"
+	if want == 1 {
+		plural = ""
+	}
+
+	return fmt.Errorf("%s() expects exactly %d argument%s", name, want, plural)
+}
+
+// An error of the OS, as reported by a function.
+func osError(name string, err error) error {
+	return fmt.Errorf("%s() failed: %s", name, err)
+}
+
+func stringArray(values []string) *ArrayType {
+	elements := []DataType{}
+	for _, value := range values {
+		elements = append(elements, &StringType{Value: value})
+	}
+
+	return &ArrayType{Elements: elements}
+}
+
+// Copy a file, keeping its permissions.
+func copyFile(from, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	destination, err := os.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return err
+	}
+
+	return destination.Close()
+}
@@ -0,0 +1,233 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/reporter"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runtime functions behind the HTTP module.
+var httpRuntime = map[string]runtimeFunc{
+
+	// runtime_http_request(method String, url String, body String, headers Dictionary, timeout Int) -> Dictionary
+	"runtime_http_request": func(args ...DataType) (DataType, error) {
+		if len(args) != 5 {
+			return nil, fmt.Errorf("runtime_http_request() expects exactly 5 arguments")
+		}
+
+		values, err := stringArguments("runtime_http_request", args[:3], 3)
+		if err != nil {
+			return nil, err
+		}
+		method, url, body := values[0], values[1], values[2]
+		name := "HTTP." + strings.ToLower(method)
+
+		headers, ok := args[3].(*DictionaryType)
+		if !ok {
+			return nil, fmt.Errorf("%s() expects headers as a Dictionary", name)
+		}
+
+		timeout, ok := args[4].(*IntegerType)
+		if !ok || timeout.Big != nil || timeout.Value < 0 {
+			return nil, fmt.Errorf("%s() expects timeout as an Int of milliseconds", name)
+		}
+
+		request, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("%s() invalid request: %s", name, err)
+		}
+
+		for _, pair := range headers.Pairs {
+			request.Header.Set(httpText(pair.Key), httpText(pair.Value))
+		}
+
+		client := &http.Client{Timeout: time.Duration(timeout.Value) * time.Millisecond}
+		response, err := client.Do(request)
+		if err != nil {
+			return nil, fmt.Errorf("%s() failed: %s", name, err)
+		}
+		defer response.Body.Close()
+
+		contents, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s() failed: %s", name, err)
+		}
+
+		out := NewDictionary()
+		out.Set(&AtomType{Value: "status"}, &IntegerType{Value: int64(response.StatusCode)})
+		out.Set(&AtomType{Value: "headers"}, httpHeaders(response.Header))
+		out.Set(&AtomType{Value: "body"}, &StringType{Value: string(contents)})
+
+		return out, nil
+	},
+}
+
+func init() {
+	for name, fn := range httpRuntime {
+		runtime[name] = fn
+	}
+}
+
+// runtime_http_serve(port Int, handler Function)
+// Serve requests on a port until the program is
+// stopped, calling the handler for each of them.
+func runtimeHTTPServe(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	if len(node.Arguments.Elements) != 2 {
+		i.reportError(node, "HTTP.serve() expects exactly 2 arguments")
+		return nil
+	}
+
+	port := i.Interpret(node.Arguments.Elements[0], scope)
+	handler := i.Interpret(node.Arguments.Elements[1], scope)
+	if port == nil || handler == nil {
+		return nil
+	}
+
+	if _, ok := port.(*IntegerType); !ok {
+		i.reportError(node, "HTTP.serve() expects the port as an Int")
+		return nil
+	}
+
+	if handler.Type() != FUNCTION_TYPE {
+		i.reportError(node, "HTTP.serve() expects the handler as a Function")
+		return nil
+	}
+
+	address := fmt.Sprintf(":%s", port.Inspect())
+	if err := http.ListenAndServe(address, i.httpHandler(node, handler, scope)); err != nil {
+		i.reportError(node, fmt.Sprintf("HTTP.serve() failed: %s", err))
+		return nil
+	}
+
+	return &BooleanType{Value: true}
+}
+
+// An http.Handler calling an Aria function with the
+// request dictionary. The interpreter runs a single
+// request at a time. Errors of the function are written
+// to stderr and answered with a 500.
+func (i *Interpreter) httpHandler(node *ast.FunctionCall, handler DataType, scope *Scope) http.Handler {
+	var mutex sync.Mutex
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		query := NewDictionary()
+		for _, name := range sortedKeys(r.URL.Query()) {
+			query.Set(&StringType{Value: name}, &StringType{Value: r.URL.Query().Get(name)})
+		}
+
+		request := NewDictionary()
+		request.Set(&AtomType{Value: "method"}, &StringType{Value: r.Method})
+		request.Set(&AtomType{Value: "path"}, &StringType{Value: r.URL.Path})
+		request.Set(&AtomType{Value: "query"}, query)
+		request.Set(&AtomType{Value: "headers"}, httpHeaders(r.Header))
+		request.Set(&AtomType{Value: "body"}, &StringType{Value: string(body)})
+
+		count := reporter.CountErrors()
+		response := i.callWithValues(node, node.Arguments.Elements[1], handler, []DataType{request}, scope)
+		if reporter.CountErrors() > count {
+			for _, message := range reporter.GetErrors()[count:] {
+				fmt.Fprintln(os.Stderr, message)
+			}
+			reporter.TruncateErrors(count)
+			response = nil
+		}
+
+		if err := writeHTTPResponse(w, response); err != nil {
+			fmt.Fprintf(os.Stderr, "HTTP.serve() %s\n", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Answer a request with the value of the handler: a
+// String as the body or a Dictionary with the :status,
+// :headers and :body.
+func writeHTTPResponse(w http.ResponseWriter, response DataType) error {
+	switch response := response.(type) {
+	case nil:
+		return fmt.Errorf("handler failed")
+	case *StringType:
+		w.Write([]byte(response.Value))
+	case *DictionaryType:
+		status := http.StatusOK
+		if value, ok := response.Get(&AtomType{Value: "status"}); ok {
+			code, ok := value.(*IntegerType)
+			if !ok || code.Big != nil || code.Value < 100 || code.Value > 999 {
+				return fmt.Errorf("handler returned an invalid :status '%s'", value.Inspect())
+			}
+			status = int(code.Value)
+		}
+
+		if value, ok := response.Get(&AtomType{Value: "headers"}); ok {
+			headers, ok := value.(*DictionaryType)
+			if !ok {
+				return fmt.Errorf("handler returned :headers that aren't a Dictionary")
+			}
+			for _, pair := range headers.Pairs {
+				w.Header().Set(httpText(pair.Key), httpText(pair.Value))
+			}
+		}
+
+		body := ""
+		if value, ok := response.Get(&AtomType{Value: "body"}); ok {
+			body = httpText(value)
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	default:
+		return fmt.Errorf("handler returned a %s instead of a String or Dictionary", response.Type())
+	}
+
+	return nil
+}
+
+// Headers as a dictionary of their names, sorted, with
+// multiple values joined by commas.
+func httpHeaders(header http.Header) *DictionaryType {
+	headers := NewDictionary()
+	for _, name := range sortedKeys(header) {
+		headers.Set(&StringType{Value: name}, &StringType{Value: strings.Join(header[name], ", ")})
+	}
+
+	return headers
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Text of headers and bodies. Strings and Atoms are
+// written as they are, anything else as it's printed.
+func httpText(value DataType) string {
+	switch value := value.(type) {
+	case *StringType:
+		return value.Value
+	case *AtomType:
+		return value.Value
+	}
+
+	return value.Inspect()
+}
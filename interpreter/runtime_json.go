@@ -0,0 +1,231 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Runtime functions behind the JSON module.
+var jsonRuntime = map[string]runtimeFunc{
+
+	// runtime_json_parse(String) -> Any
+	"runtime_json_parse": func(args ...DataType) (DataType, error) {
+		values, err := stringArguments("JSON.parse", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := parseJSON(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("JSON.parse() %s", err)
+		}
+
+		return value, nil
+	},
+
+	// runtime_json_stringify(Any, pretty Bool) -> String
+	"runtime_json_stringify": func(args ...DataType) (DataType, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("JSON.stringify() expects exactly 2 arguments")
+		}
+
+		pretty, ok := args[1].(*BooleanType)
+		if !ok {
+			return nil, fmt.Errorf("JSON.stringify() expects pretty as a Bool")
+		}
+
+		var out bytes.Buffer
+		if err := writeJSON(&out, args[0]); err != nil {
+			return nil, fmt.Errorf("JSON.stringify() %s", err)
+		}
+
+		if pretty.Value {
+			var indented bytes.Buffer
+			json.Indent(&indented, out.Bytes(), "", "  ")
+			return &StringType{Value: indented.String()}, nil
+		}
+
+		return &StringType{Value: out.String()}, nil
+	},
+}
+
+func init() {
+	for name, fn := range jsonRuntime {
+		runtime[name] = fn
+	}
+}
+
+// Parse a JSON document. Objects become dictionaries
+// keeping the order of their keys, numbers without a
+// fraction or exponent become Ints.
+func parseJSON(source string) (DataType, error) {
+	// The whole document is checked first, as the errors
+	// of the decoder's tokens don't point at the byte
+	// that's wrong.
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(source), &raw); err != nil {
+		return nil, jsonError(err)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(source))
+	decoder.UseNumber()
+
+	return parseJSONValue(decoder)
+}
+
+func parseJSONValue(decoder *json.Decoder) (DataType, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := tok.(type) {
+	case json.Delim:
+		switch value {
+		case '[':
+			array := &ArrayType{Elements: []DataType{}}
+			for decoder.More() {
+				element, err := parseJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array.Elements = append(array.Elements, element)
+			}
+			_, err := decoder.Token()
+			return array, err
+		case '{':
+			dict := NewDictionary()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				element, err := parseJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				dict.Set(&StringType{Value: key.(string)}, element)
+			}
+			_, err := decoder.Token()
+			return dict, err
+		}
+	case string:
+		return &StringType{Value: value}, nil
+	case bool:
+		return &BooleanType{Value: value}, nil
+	case nil:
+		return NIL, nil
+	case json.Number:
+		if integer, ok := new(big.Int).SetString(value.String(), 10); ok {
+			return newInteger(integer), nil
+		}
+		float, err := strconv.ParseFloat(value.String(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("number %s out of range", value)
+		}
+		return &FloatType{Value: float}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %v", tok)
+}
+
+// Syntax errors with the offset of the byte they
+// happened at, counting from zero. A document that ends
+// too early is reported at its end.
+func jsonError(err error) error {
+	syntax, ok := err.(*json.SyntaxError)
+	if !ok {
+		return err
+	}
+
+	if syntax.Error() == "unexpected end of JSON input" {
+		return fmt.Errorf("unexpected end of input at byte %d", syntax.Offset)
+	}
+
+	return fmt.Errorf("%s at byte %d", syntax, syntax.Offset-1)
+}
+
+// Write a value as compact JSON. Dictionaries keep the
+// order of their pairs, atoms are written as strings.
+func writeJSON(out *bytes.Buffer, value DataType) error {
+	switch value := value.(type) {
+	case *NilType:
+		out.WriteString("null")
+	case *BooleanType:
+		out.WriteString(strconv.FormatBool(value.Value))
+	case *IntegerType:
+		out.WriteString(value.Inspect())
+	case *FloatType:
+		if math.IsNaN(value.Value) || math.IsInf(value.Value, 0) {
+			return fmt.Errorf("can't encode the non-finite Float %s", value.Inspect())
+		}
+		// Floats stay Floats when parsed back.
+		float := strconv.FormatFloat(value.Value, 'g', -1, 64)
+		if !strings.ContainsAny(float, ".e") {
+			float += ".0"
+		}
+		out.WriteString(float)
+	case *DecimalType:
+		out.WriteString(value.Inspect())
+	case *StringType:
+		writeJSONString(out, value.Value)
+	case *AtomType:
+		writeJSONString(out, value.Value)
+	case *SetType:
+		// Sets are written as arrays.
+		return writeJSON(out, &ArrayType{Elements: value.Elements})
+	case *ArrayType:
+		out.WriteString("[")
+		for index, element := range value.Elements {
+			if index > 0 {
+				out.WriteString(",")
+			}
+			if err := writeJSON(out, element); err != nil {
+				return err
+			}
+		}
+		out.WriteString("]")
+	case *DictionaryType:
+		out.WriteString("{")
+		for index, pair := range value.Pairs {
+			if index > 0 {
+				out.WriteString(",")
+			}
+
+			switch key := pair.Key.(type) {
+			case *StringType:
+				writeJSONString(out, key.Value)
+			case *AtomType:
+				writeJSONString(out, key.Value)
+			case *IntegerType:
+				writeJSONString(out, key.Inspect())
+			default:
+				return fmt.Errorf("can't encode a %s key, only String, Atom and Int", pair.Key.Type())
+			}
+
+			out.WriteString(":")
+			if err := writeJSON(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteString("}")
+	default:
+		return fmt.Errorf("can't encode a %s", value.Type())
+	}
+
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, value string) {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+
+	// The encoder ends values with a line break.
+	out.Truncate(out.Len() - 1)
+}
@@ -0,0 +1,195 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"os"
+	"os/exec"
+)
+
+// SetArgs sets the command line arguments of the
+// script, as returned by OS.args().
+func (i *Interpreter) SetArgs(args []string) {
+	i.args = args
+}
+
+// SetOS enables or disables the functions of the OS
+// module, which are enabled by default. Disabled, they
+// fail with a runtime error.
+func (i *Interpreter) SetOS(enabled bool) {
+	i.osDisabled = !enabled
+}
+
+// SetExit sets the function OS.exit() calls, which is
+// os.Exit by default.
+func (i *Interpreter) SetExit(exit func(code int)) {
+	i.exit = exit
+}
+
+// Check that the OS module is enabled and interpret the
+// arguments of one of its functions.
+func (i *Interpreter) osArguments(node *ast.FunctionCall, scope *Scope, name string, count int) ([]DataType, bool) {
+	if i.osDisabled {
+		i.reportError(node, fmt.Sprintf("%s() is disabled in this interpreter", name))
+		return nil, false
+	}
+
+	if err := argumentCount(name, len(node.Arguments.Elements), count); err != nil {
+		i.reportError(node, err.Error())
+		return nil, false
+	}
+
+	args := []DataType{}
+	for _, element := range node.Arguments.Elements {
+		value := i.Interpret(element, scope)
+		if value == nil {
+			return nil, false
+		}
+		args = append(args, value)
+	}
+
+	return args, true
+}
+
+// runtime_os_args() -> Array
+func runtimeOSArgs(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	if _, ok := i.osArguments(node, scope, "OS.args", 0); !ok {
+		return nil
+	}
+
+	return stringArray(i.args)
+}
+
+// runtime_os_env(name String) -> String
+// Nil when the variable isn't set.
+func runtimeOSEnv(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	args, ok := i.osArguments(node, scope, "OS.env", 1)
+	if !ok {
+		return nil
+	}
+
+	names, err := stringArguments("OS.env", args, 1)
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	value, ok := os.LookupEnv(names[0])
+	if !ok {
+		return NIL
+	}
+
+	return &StringType{Value: value}
+}
+
+// runtime_os_set_env(name String, value String) -> Bool
+func runtimeOSSetEnv(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	args, ok := i.osArguments(node, scope, "OS.setEnv", 2)
+	if !ok {
+		return nil
+	}
+
+	values, err := stringArguments("OS.setEnv", args, 2)
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	if err := os.Setenv(values[0], values[1]); err != nil {
+		i.reportError(node, osError("OS.setEnv", err).Error())
+		return nil
+	}
+
+	return &BooleanType{Value: true}
+}
+
+// runtime_os_cwd() -> String
+func runtimeOSCwd(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	if _, ok := i.osArguments(node, scope, "OS.cwd", 0); !ok {
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		i.reportError(node, osError("OS.cwd", err).Error())
+		return nil
+	}
+
+	return &StringType{Value: cwd}
+}
+
+// runtime_os_exit(code Int)
+func runtimeOSExit(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	args, ok := i.osArguments(node, scope, "OS.exit", 1)
+	if !ok {
+		return nil
+	}
+
+	code, ok := args[0].(*IntegerType)
+	if !ok || code.Big != nil {
+		i.reportError(node, "OS.exit() expects the code as an Int")
+		return nil
+	}
+
+	exit := i.exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(int(code.Value))
+
+	// Only reached when the exit function returns, so
+	// the rest of the program doesn't run.
+	i.stopped = true
+	return nil
+}
+
+// runtime_os_exec(command String, args Array) -> Dictionary
+// Commands that run and fail aren't errors, their
+// :status tells.
+func runtimeOSExec(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	args, ok := i.osArguments(node, scope, "OS.exec", 2)
+	if !ok {
+		return nil
+	}
+
+	command, ok := args[0].(*StringType)
+	if !ok {
+		i.reportError(node, "OS.exec() expects the command as a String")
+		return nil
+	}
+
+	array, ok := args[1].(*ArrayType)
+	if !ok {
+		i.reportError(node, "OS.exec() expects the arguments as an Array")
+		return nil
+	}
+
+	arguments, err := stringArguments("OS.exec", array.Elements, len(array.Elements))
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command.Value, arguments...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	status := 0
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			i.reportError(node, osError("OS.exec", err).Error())
+			return nil
+		}
+		status = exitErr.ExitCode()
+	}
+
+	out := NewDictionary()
+	out.Set(&AtomType{Value: "stdout"}, &StringType{Value: stdout.String()})
+	out.Set(&AtomType{Value: "stderr"}, &StringType{Value: stderr.String()})
+	out.Set(&AtomType{Value: "status"}, &IntegerType{Value: int64(status)})
+
+	return out
+}
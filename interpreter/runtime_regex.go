@@ -0,0 +1,246 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/reporter"
+	"regexp"
+)
+
+// Runtime functions behind the Regex module. They take
+// the string first, so they work with pipes.
+var regexRuntime = map[string]runtimeFunc{
+
+	// runtime_regex_new(pattern String) -> Regex
+	"runtime_regex_new": func(args ...DataType) (DataType, error) {
+		patterns, err := stringArguments("Regex.new", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		regex, err := regexp.Compile(patterns[0])
+		if err != nil {
+			return nil, fmt.Errorf("Regex.new() invalid regex: %s", err)
+		}
+
+		return &RegexType{Value: regex}, nil
+	},
+
+	// runtime_regex_test(String, Regex) -> Bool
+	"runtime_regex_test": func(args ...DataType) (DataType, error) {
+		str, regex, err := regexArguments("Regex.match?", args)
+		if err != nil {
+			return nil, err
+		}
+
+		return &BooleanType{Value: regex.MatchString(str)}, nil
+	},
+
+	// runtime_regex_find(String, Regex) -> String
+	// Nil when nothing matches.
+	"runtime_regex_find": func(args ...DataType) (DataType, error) {
+		str, regex, err := regexArguments("Regex.find", args)
+		if err != nil {
+			return nil, err
+		}
+
+		location := regex.FindStringIndex(str)
+		if location == nil {
+			return NIL, nil
+		}
+
+		return &StringType{Value: str[location[0]:location[1]]}, nil
+	},
+
+	// runtime_regex_find_all(String, Regex) -> Array
+	"runtime_regex_find_all": func(args ...DataType) (DataType, error) {
+		str, regex, err := regexArguments("Regex.findAll", args)
+		if err != nil {
+			return nil, err
+		}
+
+		return stringArray(regex.FindAllString(str, -1)), nil
+	},
+
+	// runtime_regex_captures(String, Regex) -> Array
+	// The match followed by its groups, or nil when
+	// nothing matches.
+	"runtime_regex_captures": func(args ...DataType) (DataType, error) {
+		str, regex, err := regexArguments("Regex.captures", args)
+		if err != nil {
+			return nil, err
+		}
+
+		match := regex.FindStringSubmatchIndex(str)
+		if match == nil {
+			return NIL, nil
+		}
+
+		return regexGroups(str, match), nil
+	},
+
+	// runtime_regex_captures_all(String, Regex) -> Array
+	"runtime_regex_captures_all": func(args ...DataType) (DataType, error) {
+		str, regex, err := regexArguments("Regex.capturesAll", args)
+		if err != nil {
+			return nil, err
+		}
+
+		matches := []DataType{}
+		for _, match := range regex.FindAllStringSubmatchIndex(str, -1) {
+			matches = append(matches, regexGroups(str, match))
+		}
+
+		return &ArrayType{Elements: matches}, nil
+	},
+
+	// runtime_regex_named(String, Regex) -> Dictionary
+	// Named groups by name, or nil when nothing
+	// matches.
+	"runtime_regex_named": func(args ...DataType) (DataType, error) {
+		str, regex, err := regexArguments("Regex.named", args)
+		if err != nil {
+			return nil, err
+		}
+
+		match := regex.FindStringSubmatchIndex(str)
+		if match == nil {
+			return NIL, nil
+		}
+
+		groups := regexGroups(str, match).Elements
+		named := NewDictionary()
+		for index, name := range regex.SubexpNames() {
+			if name != "" {
+				named.Set(&StringType{Value: name}, groups[index])
+			}
+		}
+
+		return named, nil
+	},
+
+	// runtime_regex_split(String, Regex, limit Int) -> Array
+	"runtime_regex_split": func(args ...DataType) (DataType, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("Regex.split() expects exactly 3 arguments")
+		}
+
+		str, regex, err := regexArguments("Regex.split", args[:2])
+		if err != nil {
+			return nil, err
+		}
+
+		limit, err := intArguments("Regex.split", args[2:])
+		if err != nil {
+			return nil, err
+		}
+
+		return stringArray(regex.Split(str, limit[0])), nil
+	},
+}
+
+func init() {
+	for name, fn := range regexRuntime {
+		runtime[name] = fn
+	}
+}
+
+// runtime_regex_replace(String, Regex, replacement String|Function) -> String
+// A String replacement expands $1 or ${name} to the
+// groups. A Function gets each match and returns the
+// String that replaces it.
+func runtimeRegexReplace(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	if len(node.Arguments.Elements) != 3 {
+		i.reportError(node, "Regex.replace() expects exactly 3 arguments")
+		return nil
+	}
+
+	args := []DataType{}
+	for _, element := range node.Arguments.Elements {
+		value := i.Interpret(element, scope)
+		if value == nil {
+			return nil
+		}
+		args = append(args, value)
+	}
+
+	str, regex, err := regexArguments("Regex.replace", args[:2])
+	if err != nil {
+		i.reportError(node, err.Error())
+		return nil
+	}
+
+	switch replacement := args[2].(type) {
+	case *StringType:
+		return &StringType{Value: regex.ReplaceAllString(str, replacement.Value)}
+	case *FunctionType:
+		// Once a call fails, the rest of the matches
+		// are left alone.
+		count := reporter.CountErrors()
+		failed := false
+		replaced := regex.ReplaceAllStringFunc(str, func(match string) string {
+			if failed {
+				return match
+			}
+
+			value := i.callWithValues(node, node.Arguments.Elements[2], replacement, []DataType{&StringType{Value: match}}, scope)
+			if reporter.CountErrors() > count {
+				failed = true
+				return match
+			}
+
+			out, ok := value.(*StringType)
+			if !ok {
+				i.reportError(node, fmt.Sprintf("Regex.replace() expects the function to return a String but got '%s'", value.Type()))
+				failed = true
+				return match
+			}
+
+			return out.Value
+		})
+
+		if failed {
+			return nil
+		}
+
+		return &StringType{Value: replaced}
+	default:
+		i.reportError(node, "Regex.replace() expects the replacement as a String or Function")
+		return nil
+	}
+}
+
+// Check the String and Regex arguments of a
+// runtime function.
+func regexArguments(name string, args []DataType) (string, *regexp.Regexp, error) {
+	if len(args) != 2 {
+		return "", nil, fmt.Errorf("%s() expects exactly 2 arguments", name)
+	}
+
+	str, ok := args[0].(*StringType)
+	if !ok {
+		return "", nil, fmt.Errorf("%s() expects a String but got '%s'", name, args[0].Type())
+	}
+
+	regex, ok := args[1].(*RegexType)
+	if !ok {
+		return "", nil, fmt.Errorf("%s() expects a Regex but got '%s'", name, args[1].Type())
+	}
+
+	return str.Value, regex.Value, nil
+}
+
+// The text of a match and its groups, from their
+// indexes. Groups that didn't take part are nil.
+func regexGroups(str string, match []int) *ArrayType {
+	groups := []DataType{}
+	for index := 0; index < len(match); index += 2 {
+		if match[index] < 0 {
+			groups = append(groups, NIL)
+			continue
+		}
+		groups = append(groups, &StringType{Value: str[match[index]:match[index+1]]})
+	}
+
+	return &ArrayType{Elements: groups}
+}
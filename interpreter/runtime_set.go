@@ -0,0 +1,91 @@
+package interpreter
+
+import "fmt"
+
+// Runtime functions behind the Set module. Sets are
+// never changed in place: adding or deleting returns
+// a new set.
+var setRuntime = map[string]runtimeFunc{
+
+	// runtime_set_size(Set) -> Int
+	"runtime_set_size": func(args ...DataType) (DataType, error) {
+		set, err := setArgument("Set.size", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &IntegerType{Value: int64(set.Len())}, nil
+	},
+
+	// runtime_set_add(Set, element Any) -> Set
+	"runtime_set_add": func(args ...DataType) (DataType, error) {
+		set, err := setArgument("Set.add", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		added := NewSet(set.Elements...)
+		added.Add(args[1])
+
+		return added, nil
+	},
+
+	// runtime_set_delete(Set, element Any) -> Set
+	"runtime_set_delete": func(args ...DataType) (DataType, error) {
+		set, err := setArgument("Set.delete", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		deleted := NewSet()
+		for _, element := range set.Elements {
+			if dictionaryKey(element) != dictionaryKey(args[1]) {
+				deleted.Add(element)
+			}
+		}
+
+		return deleted, nil
+	},
+
+	// runtime_set_subset(Set, other Set) -> Bool
+	"runtime_set_subset": func(args ...DataType) (DataType, error) {
+		set, err := setArgument("Set.subset?", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		other, ok := args[1].(*SetType)
+		if !ok {
+			return nil, fmt.Errorf("Set.subset?() expects a Set to compare with")
+		}
+
+		for _, element := range set.Elements {
+			if !other.Contains(element) {
+				return FALSE, nil
+			}
+		}
+
+		return TRUE, nil
+	},
+}
+
+func init() {
+	for name, fn := range setRuntime {
+		runtime[name] = fn
+	}
+}
+
+// Check the arguments of a runtime function
+// taking a Set first.
+func setArgument(name string, args []DataType, count int) (*SetType, error) {
+	if err := argumentCount(name, len(args), count); err != nil {
+		return nil, err
+	}
+
+	set, ok := args[0].(*SetType)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects a Set but got '%s'", name, args[0].Type())
+	}
+
+	return set, nil
+}
@@ -0,0 +1,290 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/reporter"
+	"time"
+)
+
+// Times are Ints of milliseconds since the Unix epoch
+// and durations Ints of milliseconds, so they work with
+// the usual arithmetic. Zones are IANA names like
+// "Europe/Tirane", "UTC" or "Local".
+
+// Start of the monotonic clock.
+var clockStart = time.Now()
+
+// Runtime functions behind the Time module.
+var timeRuntime = map[string]runtimeFunc{
+
+	// runtime_time_now() -> Int
+	"runtime_time_now": func(args ...DataType) (DataType, error) {
+		return &IntegerType{Value: toMilliseconds(time.Now())}, nil
+	},
+
+	// runtime_time_unix() -> Int
+	"runtime_time_unix": func(args ...DataType) (DataType, error) {
+		return &IntegerType{Value: time.Now().Unix()}, nil
+	},
+
+	// runtime_time_monotonic() -> Int
+	"runtime_time_monotonic": func(args ...DataType) (DataType, error) {
+		return &IntegerType{Value: int64(time.Since(clockStart))}, nil
+	},
+
+	// runtime_time_format(time Int, layout String, zone String) -> String
+	"runtime_time_format": func(args ...DataType) (DataType, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("Time.format() expects exactly 3 arguments")
+		}
+
+		instant, err := timeArgument("Time.format", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := stringArguments("Time.format", args[1:], 2)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := timeZone("Time.format", values[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return &StringType{Value: instant.In(location).Format(values[0])}, nil
+	},
+
+	// runtime_time_parse(text String, layout String, zone String) -> Int
+	"runtime_time_parse": func(args ...DataType) (DataType, error) {
+		values, err := stringArguments("Time.parse", args, 3)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := timeZone("Time.parse", values[2])
+		if err != nil {
+			return nil, err
+		}
+
+		instant, err := time.ParseInLocation(values[1], values[0], location)
+		if err != nil {
+			return nil, fmt.Errorf("Time.parse() %s", err)
+		}
+
+		return &IntegerType{Value: toMilliseconds(instant)}, nil
+	},
+
+	// runtime_time_parts(time Int, zone String) -> Dictionary
+	"runtime_time_parts": func(args ...DataType) (DataType, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("Time.parts() expects exactly 2 arguments")
+		}
+
+		instant, err := timeArgument("Time.parts", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		zones, err := stringArguments("Time.parts", args[1:], 1)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := timeZone("Time.parts", zones[0])
+		if err != nil {
+			return nil, err
+		}
+
+		local := instant.In(location)
+		name, offset := local.Zone()
+
+		parts := NewDictionary()
+		parts.Set(&AtomType{Value: "year"}, &IntegerType{Value: int64(local.Year())})
+		parts.Set(&AtomType{Value: "month"}, &IntegerType{Value: int64(local.Month())})
+		parts.Set(&AtomType{Value: "day"}, &IntegerType{Value: int64(local.Day())})
+		parts.Set(&AtomType{Value: "hour"}, &IntegerType{Value: int64(local.Hour())})
+		parts.Set(&AtomType{Value: "minute"}, &IntegerType{Value: int64(local.Minute())})
+		parts.Set(&AtomType{Value: "second"}, &IntegerType{Value: int64(local.Second())})
+		parts.Set(&AtomType{Value: "millisecond"}, &IntegerType{Value: int64(local.Nanosecond() / int(time.Millisecond))})
+		parts.Set(&AtomType{Value: "weekday"}, &IntegerType{Value: int64(local.Weekday())})
+		parts.Set(&AtomType{Value: "zone"}, &StringType{Value: name})
+		parts.Set(&AtomType{Value: "offset"}, &IntegerType{Value: int64(offset)})
+
+		return parts, nil
+	},
+
+	// runtime_time_date(year Int, month Int, day Int, hour Int, minute Int, second Int, zone String) -> Int
+	"runtime_time_date": func(args ...DataType) (DataType, error) {
+		if len(args) != 7 {
+			return nil, fmt.Errorf("Time.date() expects exactly 7 arguments")
+		}
+
+		fields, err := intArguments("Time.date", args[:6])
+		if err != nil {
+			return nil, err
+		}
+
+		zones, err := stringArguments("Time.date", args[6:], 1)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := timeZone("Time.date", zones[0])
+		if err != nil {
+			return nil, err
+		}
+
+		instant := time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, location)
+		return &IntegerType{Value: toMilliseconds(instant)}, nil
+	},
+
+	// runtime_time_add_date(time Int, years Int, months Int, days Int, zone String) -> Int
+	"runtime_time_add_date": func(args ...DataType) (DataType, error) {
+		if len(args) != 5 {
+			return nil, fmt.Errorf("Time.addDate() expects exactly 5 arguments")
+		}
+
+		instant, err := timeArgument("Time.addDate", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := intArguments("Time.addDate", args[1:4])
+		if err != nil {
+			return nil, err
+		}
+
+		zones, err := stringArguments("Time.addDate", args[4:], 1)
+		if err != nil {
+			return nil, err
+		}
+
+		// Days are added on the calendar of the zone, so
+		// they stay whole across daylight saving changes.
+		location, err := timeZone("Time.addDate", zones[0])
+		if err != nil {
+			return nil, err
+		}
+
+		added := instant.In(location).AddDate(fields[0], fields[1], fields[2])
+		return &IntegerType{Value: toMilliseconds(added)}, nil
+	},
+
+	// runtime_time_duration(String) -> Int
+	"runtime_time_duration": func(args ...DataType) (DataType, error) {
+		values, err := stringArguments("Time.duration", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		duration, err := time.ParseDuration(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("Time.duration() invalid duration '%s'", values[0])
+		}
+
+		return &IntegerType{Value: int64(duration / time.Millisecond)}, nil
+	},
+
+	// runtime_time_sleep(milliseconds Int) -> Bool
+	"runtime_time_sleep": func(args ...DataType) (DataType, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Time.sleep() expects exactly 1 argument")
+		}
+
+		milliseconds, err := intArguments("Time.sleep", args)
+		if err != nil {
+			return nil, err
+		}
+
+		time.Sleep(time.Duration(milliseconds[0]) * time.Millisecond)
+
+		return &BooleanType{Value: true}, nil
+	},
+}
+
+func init() {
+	for name, fn := range timeRuntime {
+		runtime[name] = fn
+	}
+}
+
+// runtime_time_measure(Function) -> Float
+// Milliseconds taken by a call of a function without
+// arguments, on the monotonic clock.
+func runtimeTimeMeasure(i *Interpreter, node *ast.FunctionCall, scope *Scope) DataType {
+	if len(node.Arguments.Elements) != 1 {
+		i.reportError(node, "Time.measure() expects exactly 1 argument")
+		return nil
+	}
+
+	fn := i.Interpret(node.Arguments.Elements[0], scope)
+	if fn == nil {
+		return nil
+	}
+
+	if fn.Type() != FUNCTION_TYPE {
+		i.reportError(node, "Time.measure() expects a Function")
+		return nil
+	}
+
+	count := reporter.CountErrors()
+	start := time.Now()
+	i.callWithValues(node, node.Arguments.Elements[0], fn, []DataType{}, scope)
+	elapsed := time.Since(start)
+	if reporter.CountErrors() > count {
+		return nil
+	}
+
+	return &FloatType{Value: float64(elapsed) / float64(time.Millisecond)}
+}
+
+// Milliseconds since the epoch. Seconds and the rest are
+// converted apart, as nanoseconds only fit in an int64
+// between the years 1678 and 2262.
+func toMilliseconds(instant time.Time) int64 {
+	return instant.Unix()*1000 + int64(instant.Nanosecond())/int64(time.Millisecond)
+}
+
+func timeArgument(name string, arg DataType) (time.Time, error) {
+	milliseconds, ok := arg.(*IntegerType)
+	if !ok || milliseconds.Big != nil {
+		return time.Time{}, fmt.Errorf("%s() expects a time as an Int of milliseconds", name)
+	}
+
+	// Floor the seconds, so times before the epoch
+	// keep a positive rest.
+	seconds, rest := milliseconds.Value/1000, milliseconds.Value%1000
+	if rest < 0 {
+		seconds--
+		rest += 1000
+	}
+
+	return time.Unix(seconds, rest*int64(time.Millisecond)), nil
+}
+
+func timeZone(name, zone string) (*time.Location, error) {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("%s() unknown time zone '%s'", name, zone)
+	}
+
+	return location, nil
+}
+
+// Check that the arguments of a runtime function
+// are all Ints that fit in an int.
+func intArguments(name string, args []DataType) ([]int, error) {
+	values := []int{}
+	for _, arg := range args {
+		integer, ok := arg.(*IntegerType)
+		if !ok || integer.Big != nil {
+			return nil, fmt.Errorf("%s() expects Int arguments but got '%s'", name, arg.Type())
+		}
+		values = append(values, int(integer.Value))
+	}
+
+	return values, nil
+}
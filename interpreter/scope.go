@@ -1,5 +1,7 @@
 package interpreter
 
+import "sort"
+
 // Scope represents the variable scope.
 type Scope struct {
 	store  map[string]DataType
@@ -32,6 +34,45 @@ func (s *Scope) Read(name string) (DataType, bool) {
 	return value, ok
 }
 
+// Names returns the variables visible from the
+// scope, including those of its parents.
+func (s *Scope) Names() []string {
+	seen := map[string]bool{}
+	names := []string{}
+
+	for scope := s; scope != nil; scope = scope.parent {
+		for name := range scope.store {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Locals returns the variables of the scope
+// alone, without its parents.
+func (s *Scope) Locals() []string {
+	names := []string{}
+	for name := range s.store {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Parent returns the scope this one inherits
+// from, if any.
+func (s *Scope) Parent() *Scope {
+	return s.parent
+}
+
 // Write saves a variable to the scope.
 func (s *Scope) Write(name string, value DataType) {
 	s.store[name] = value
@@ -87,3 +87,33 @@ func TestScopeMerge(t *testing.T) {
 		t.Errorf("Expected %d but got %d", 10, value.Value)
 	}
 }
+
+func TestScopeNames(t *testing.T) {
+	sp := NewScope()
+	sp.Write("b", &IntegerType{Value: 1})
+	s := NewScopeFrom(sp)
+	s.Write("a", &IntegerType{Value: 2})
+	s.Write("b", &IntegerType{Value: 3})
+
+	names := s.Names()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Expected [a b] but got %v", names)
+	}
+}
+
+func TestScopeLocals(t *testing.T) {
+	sp := NewScope()
+	sp.Write("c", &IntegerType{Value: 1})
+	s := NewScopeFrom(sp)
+	s.Write("b", &IntegerType{Value: 2})
+	s.Write("a", &IntegerType{Value: 3})
+
+	locals := s.Locals()
+	if len(locals) != 2 || locals[0] != "a" || locals[1] != "b" {
+		t.Errorf("Expected [a b] but got %v", locals)
+	}
+
+	if s.Parent() != sp || sp.Parent() != nil {
+		t.Errorf("Expected the parent scope")
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/decimal"
+	"github.com/fadion/aria/token"
+	"math/big"
+	"regexp"
 	"strings"
 )
 
@@ -11,11 +15,14 @@ import (
 const (
 	INTEGER_TYPE     = "Int"
 	FLOAT_TYPE       = "Float"
+	DECIMAL_TYPE     = "Decimal"
 	STRING_TYPE      = "String"
 	ATOM_TYPE        = "Atom"
+	REGEX_TYPE       = "Regex"
 	BOOLEAN_TYPE     = "Bool"
 	ARRAY_TYPE       = "Array"
 	DICTIONARY_TYPE  = "Dictionary"
+	SET_TYPE         = "Set"
 	NIL_TYPE         = "Nil"
 	FUNCTION_TYPE    = "Function"
 	RETURN_TYPE      = "Return"
@@ -43,6 +50,7 @@ type DataType interface {
 type ModuleType struct {
 	Name *ast.Identifier
 	Body *ast.BlockStatement
+	File string
 }
 
 func (t *ModuleType) Type() string { return MODULE_TYPE }
@@ -58,13 +66,22 @@ func (t *ModuleType) Inspect() string {
 	return out.String()
 }
 
-// IntegerType for integers.
+// IntegerType for integers. Values that don't fit in
+// 64 bits are promoted to Big, in which case Value is
+// clamped to the closest int64.
 type IntegerType struct {
 	Value int64
+	Big   *big.Int
 }
 
-func (t *IntegerType) Type() string    { return INTEGER_TYPE }
-func (t *IntegerType) Inspect() string { return fmt.Sprintf("%d", t.Value) }
+func (t *IntegerType) Type() string { return INTEGER_TYPE }
+func (t *IntegerType) Inspect() string {
+	if t.Big != nil {
+		return t.Big.String()
+	}
+
+	return fmt.Sprintf("%d", t.Value)
+}
 
 // FloatType for floating point numbers.
 type FloatType struct {
@@ -74,6 +91,14 @@ type FloatType struct {
 func (t *FloatType) Type() string    { return FLOAT_TYPE }
 func (t *FloatType) Inspect() string { return fmt.Sprintf("%f", t.Value) }
 
+// DecimalType for exact base 10 numbers.
+type DecimalType struct {
+	Value decimal.Decimal
+}
+
+func (t *DecimalType) Type() string    { return DECIMAL_TYPE }
+func (t *DecimalType) Inspect() string { return t.Value.String() }
+
 // StringType for strings.
 type StringType struct {
 	Value string
@@ -90,6 +115,16 @@ type AtomType struct {
 func (t *AtomType) Type() string    { return ATOM_TYPE }
 func (t *AtomType) Inspect() string { return ":" + t.Value }
 
+// RegexType for compiled regular expressions.
+type RegexType struct {
+	Value *regexp.Regexp
+}
+
+func (t *RegexType) Type() string { return REGEX_TYPE }
+func (t *RegexType) Inspect() string {
+	return "~r\"" + strings.Replace(t.Value.String(), "\"", "\\\"", -1) + "\""
+}
+
 // BooleanType for boolean.
 type BooleanType struct {
 	Value bool
@@ -119,9 +154,27 @@ func (t *ArrayType) Inspect() string {
 	return out.String()
 }
 
-// DictionaryType for dictionaries.
+// DictionaryType for dictionaries. Pairs are kept
+// in insertion order, so iteration and printing
+// are deterministic.
 type DictionaryType struct {
-	Pairs map[DataType]DataType
+	Pairs []*DictionaryPair
+	index map[string]int
+}
+
+// DictionaryPair is a single key and value of
+// a dictionary.
+type DictionaryPair struct {
+	Key   DataType
+	Value DataType
+}
+
+// NewDictionary initializes an empty dictionary.
+func NewDictionary() *DictionaryType {
+	return &DictionaryType{
+		Pairs: []*DictionaryPair{},
+		index: map[string]int{},
+	}
 }
 
 func (t *DictionaryType) Type() string { return DICTIONARY_TYPE }
@@ -129,8 +182,8 @@ func (t *DictionaryType) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range t.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s => %s", key.Inspect(), value.Inspect()))
+	for _, pair := range t.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s => %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
 	out.WriteString("[")
@@ -140,6 +193,121 @@ func (t *DictionaryType) Inspect() string {
 	return out.String()
 }
 
+// Get returns the value of a key.
+func (t *DictionaryType) Get(key DataType) (DataType, bool) {
+	if idx, ok := t.index[dictionaryKey(key)]; ok {
+		return t.Pairs[idx].Value, true
+	}
+
+	return nil, false
+}
+
+// Set updates the value of an existing key or
+// appends it as a new pair.
+func (t *DictionaryType) Set(key, value DataType) {
+	hash := dictionaryKey(key)
+	if idx, ok := t.index[hash]; ok {
+		t.Pairs[idx].Value = value
+		return
+	}
+
+	t.index[hash] = len(t.Pairs)
+	t.Pairs = append(t.Pairs, &DictionaryPair{Key: key, Value: value})
+}
+
+// Delete removes a key, keeping the order of
+// the rest of the pairs.
+func (t *DictionaryType) Delete(key DataType) bool {
+	hash := dictionaryKey(key)
+	idx, ok := t.index[hash]
+	if !ok {
+		return false
+	}
+
+	t.Pairs = append(t.Pairs[:idx], t.Pairs[idx+1:]...)
+	delete(t.index, hash)
+
+	// Pairs after the deleted one moved back
+	// by one position.
+	for k, v := range t.index {
+		if v > idx {
+			t.index[k] = v - 1
+		}
+	}
+
+	return true
+}
+
+// Len returns the number of pairs.
+func (t *DictionaryType) Len() int {
+	return len(t.Pairs)
+}
+
+// SetType for sets. Elements are unique, compared by
+// type and value like dictionary keys, and kept in the
+// order they're added.
+type SetType struct {
+	Elements []DataType
+	index    map[string]bool
+}
+
+// NewSet initializes a set with the elements,
+// dropping duplicates.
+func NewSet(elements ...DataType) *SetType {
+	set := &SetType{Elements: []DataType{}, index: map[string]bool{}}
+	for _, element := range elements {
+		set.Add(element)
+	}
+
+	return set
+}
+
+func (t *SetType) Type() string { return SET_TYPE }
+func (t *SetType) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range t.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("#[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// Add appends an element that isn't in the set yet,
+// reporting if it did.
+func (t *SetType) Add(element DataType) bool {
+	hash := dictionaryKey(element)
+	if t.index[hash] {
+		return false
+	}
+
+	t.index[hash] = true
+	t.Elements = append(t.Elements, element)
+
+	return true
+}
+
+// Contains checks if an element is in the set.
+func (t *SetType) Contains(element DataType) bool {
+	return t.index[dictionaryKey(element)]
+}
+
+// Len returns the number of elements.
+func (t *SetType) Len() int {
+	return len(t.Elements)
+}
+
+// Keys are compared by type and value, so 1
+// and "1" are different keys.
+func dictionaryKey(key DataType) string {
+	return key.Type() + ":" + key.Inspect()
+}
+
 // NilType for nil.
 type NilType struct{}
 
@@ -153,6 +321,11 @@ type FunctionType struct {
 	ReturnType *ast.Identifier
 	Variadic   bool
 	Scope      *Scope
+	File       string
+	Location   token.Location
+	// Go function run instead of the body, for
+	// native Standard Library members.
+	Native func(args []DataType) (DataType, error)
 }
 
 func (t *FunctionType) Type() string { return FUNCTION_TYPE }
@@ -0,0 +1,73 @@
+package interpreter
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Split a string into its characters. By default a
+// character is a rune, but when graphemes are enabled
+// combining marks, emoji modifiers and zero width joined
+// sequences are kept together with their base rune.
+func (i *Interpreter) splitString(str string) []string {
+	chars := make([]string, 0, utf8.RuneCountInString(str))
+
+	if !i.graphemes {
+		for _, r := range str {
+			chars = append(chars, string(r))
+		}
+		return chars
+	}
+
+	start := 0
+	var prev rune = -1
+	regional := 0
+
+	for idx, r := range str {
+		if prev != -1 && !i.extendsGrapheme(prev, r, regional) {
+			chars = append(chars, str[start:idx])
+			start = idx
+			regional = 0
+		}
+
+		if i.isRegionalIndicator(r) {
+			regional++
+		}
+		prev = r
+	}
+
+	if start < len(str) {
+		chars = append(chars, str[start:])
+	}
+
+	return chars
+}
+
+// Check if a rune continues the grapheme cluster
+// started before it.
+func (i *Interpreter) extendsGrapheme(prev, r rune, regional int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return true
+	case prev == '\u200d': // Anything after a zero width joiner.
+		return true
+	case r == '\u200d':
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r >= '\ufe00' && r <= '\ufe0f': // Variation selectors.
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // Emoji skin tone modifiers.
+		return true
+	case i.isRegionalIndicator(prev) && i.isRegionalIndicator(r):
+		// Flags are made of pairs of regional indicators.
+		return regional%2 == 1
+	default:
+		return false
+	}
+}
+
+// Check if a rune is a regional indicator symbol.
+func (i *Interpreter) isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
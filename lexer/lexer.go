@@ -6,6 +6,7 @@ import (
 	"github.com/fadion/aria/reader"
 	"github.com/fadion/aria/reporter"
 	"github.com/fadion/aria/token"
+	"strings"
 )
 
 // Lexer represents the lexer.
@@ -14,9 +15,12 @@ type Lexer struct {
 	char     rune
 	row      int
 	col      int
+	start    token.Location
 	token    token.Token
 	rewinded bool
 	symbol   *Symbol
+	comments []token.Token
+	docs     map[int]string
 }
 
 // New initializes a Lexer.
@@ -24,9 +28,10 @@ func New(reader *reader.Reader) *Lexer {
 	l := &Lexer{
 		reader:   reader,
 		row:      1,
-		col:      1,
+		col:      0,
 		rewinded: false,
 		symbol:   &Symbol{},
+		docs:     map[int]string{},
 	}
 
 	// List of valid keywords.
@@ -65,6 +70,20 @@ func (l *Lexer) NextToken() token.Token {
 	// Ignore any number of sequential whitespace.
 	l.consumeWhitespace()
 
+	// Comments don't make it to the token stream, but
+	// are kept aside for tools that need them.
+	for l.char == '/' && (l.peek() == '/' || l.peek() == '*') {
+		if l.peek() == '/' {
+			l.consumeComment()
+		} else {
+			l.consumeMultilineComment()
+		}
+		l.consumeWhitespace()
+	}
+
+	// Tokens are located where they start.
+	l.start = token.Location{Row: l.row, Col: l.col}
+
 	switch {
 	case l.char == 0:
 		l.assignToken(token.EOF, "")
@@ -133,12 +152,6 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	case l.char == '/':
 		switch l.peek() {
-		case '/': // single line comment
-			l.advance()
-			l.consumeComment()
-		case '*': // multiline comment
-			l.advance()
-			l.consumeMultilineComment()
 		case '=': // /=
 			l.advance()
 			l.assignToken(token.ASSIGNDIV, "/=")
@@ -183,7 +196,12 @@ func (l *Lexer) NextToken() token.Token {
 			l.assignToken(token.BITAND, string(l.char))
 		}
 	case l.char == '~':
-		l.assignToken(token.BITNOT, string(l.char))
+		switch {
+		case l.peek() == 'r' && l.regexAhead(): // ~r"..."
+			l.consumeRegex()
+		default: // ~
+			l.assignToken(token.BITNOT, string(l.char))
+		}
 	case l.char == '!':
 		switch l.peek() {
 		case '=': // !=
@@ -198,6 +216,9 @@ func (l *Lexer) NextToken() token.Token {
 		l.assignToken(token.RPAREN, ")")
 	case l.char == '[':
 		l.assignToken(token.LBRACK, "[")
+	case l.char == '#' && l.peek() == '[': // #[ opens a set.
+		l.advance()
+		l.assignToken(token.SETBRACK, "#[")
 	case l.char == ']':
 		l.assignToken(token.RBRACK, "]")
 	case l.char == '?':
@@ -273,7 +294,7 @@ func (l *Lexer) moveLocation() {
 	switch l.char {
 	case '\n':
 		l.row += 1
-		l.col = 2
+		l.col = 1
 	default:
 		l.col += 1
 	}
@@ -284,7 +305,7 @@ func (l *Lexer) assignToken(toktype token.TokenType, value string) {
 	l.token = token.Token{
 		Type:     toktype,
 		Lexeme:   value,
-		Location: token.Location{Row: l.row, Col: l.col},
+		Location: l.start,
 	}
 }
 
@@ -376,6 +397,58 @@ loop:
 	l.assignToken(token.STRING, out.String())
 }
 
+// Check if the "r" after a tilde opens a regex
+// literal, moving to its quote if it does.
+func (l *Lexer) regexAhead() bool {
+	l.advance()
+	if l.peek() == '"' {
+		l.advance()
+		return true
+	}
+
+	l.rewind()
+	l.char = '~'
+	return false
+}
+
+// Read a regex literal. Escapes are kept for the
+// regular expression, except for the escaped double
+// quote.
+func (l *Lexer) consumeRegex() {
+	var out bytes.Buffer
+
+	// Move past the opening double quote.
+	l.advance()
+
+loop:
+	for {
+		switch l.char {
+		case '\\':
+			l.advance()
+			switch l.char {
+			case '"': // \"
+				out.WriteRune('"')
+			case 0:
+				continue
+			default:
+				out.WriteRune('\\')
+				out.WriteRune(l.char)
+			}
+		case 0:
+			l.reportError("Unterminated regex")
+			break loop
+		case '"':
+			break loop
+		default:
+			out.WriteRune(l.char)
+		}
+
+		l.advance()
+	}
+
+	l.assignToken(token.REGEX, out.String())
+}
+
 // Read a numeric literal.
 func (l *Lexer) consumeNumeric() {
 	var out bytes.Buffer
@@ -384,6 +457,7 @@ func (l *Lexer) consumeNumeric() {
 	out.WriteRune(l.char)
 	floatFound := false
 	scientificFound := false
+	decimalFound := false
 
 loop:
 	for {
@@ -407,6 +481,9 @@ loop:
 		case l.char == '.' && l.peek() == '.': // Range operator.
 			l.rewind()
 			break loop
+		case l.char == 'd' && !l.isName(l.peek()): // Decimal suffix.
+			decimalFound = true
+			break loop
 		case l.char == 0: // Don't rewind on EOF.
 			break loop
 		default:
@@ -415,7 +492,9 @@ loop:
 		}
 	}
 
-	if floatFound {
+	if decimalFound {
+		l.assignToken(token.DECIMAL, out.String())
+	} else if floatFound {
 		l.assignToken(token.FLOAT, out.String())
 	} else {
 		l.assignToken(token.INTEGER, out.String())
@@ -447,59 +526,76 @@ func (l *Lexer) consumeSpecialInteger(fn func(rune) bool) {
 	l.assignToken(token.INTEGER, ret)
 }
 
-// Read a single line comment.
+// Comments returns the comments found so far, in
+// the order they appear in source.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// Read a single line comment, stopping at the line
+// break so it still ends the statement.
 func (l *Lexer) consumeComment() {
 	var out bytes.Buffer
+	location := token.Location{Row: l.row, Col: l.col}
 
-	l.advance()
+	for l.char != '\n' && l.char != 0 {
+		out.WriteRune(l.char)
+		l.advance()
+	}
 
-loop:
-	for {
-		switch l.char {
-		case '\n', 0: // Comment ends on a line break or EOF
-			break loop
-		case '\r': // Or possibly on a \r\n
-			l.advance()
-			switch l.char {
-			case '\n', 0:
-				break loop
-			default:
-				l.reportError("Unexpected comment line ending")
-				break loop
-			}
-		default:
-			out.WriteRune(l.char)
-		}
+	text := strings.TrimRight(out.String(), "\r")
+	l.comments = append(l.comments, token.Token{Type: token.COMMENT, Lexeme: text, Location: location})
 
-		l.advance()
+	// Doc comments are /// comments on a line of their
+	// own, kept by row for the declarations below.
+	if strings.HasPrefix(text, "///") && l.token.Location.Row != location.Row {
+		l.docs[location.Row] = strings.TrimPrefix(strings.TrimPrefix(text, "///"), " ")
 	}
+}
 
-	l.assignToken(token.COMMENT, out.String())
+// DocComment returns the text of the doc comments on
+// the lines right above a row, without their slashes.
+func (l *Lexer) DocComment(row int) string {
+	lines := []string{}
+	for above := row - 1; above > 0; above-- {
+		line, ok := l.docs[above]
+		if !ok {
+			break
+		}
+		lines = append([]string{line}, lines...)
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 // Read multiline comment.
 func (l *Lexer) consumeMultilineComment() {
 	var out bytes.Buffer
+	location := token.Location{Row: l.row, Col: l.col}
+
+	// Move past the opening /*.
+	out.WriteString("/*")
+	l.advance()
+	l.advance()
 
 loop:
 	for {
-		l.advance()
-		switch l.char {
-		case '*':
-			switch l.peek() {
-			case '/': // Multiline comments end with */
-				l.advance()
-				break loop
-			}
-		case 0: // EOF and yet not comment terminator.
+		switch {
+		case l.char == '*' && l.peek() == '/': // Multiline comments end with */
+			out.WriteString("*/")
+			l.advance()
+			l.advance()
+			break loop
+		case l.char == 0: // EOF and yet not comment terminator.
 			l.reportError("Unterminated multiline comment")
 			break loop
 		default:
 			out.WriteRune(l.char)
+			l.advance()
 		}
 	}
 
-	l.assignToken(token.COMMENT, out.String())
+	l.comments = append(l.comments, token.Token{Type: token.COMMENT, Lexeme: out.String(), Location: location})
 }
 
 // Read an identifier or keyword.
@@ -103,6 +103,55 @@ func TestDataTypes(t *testing.T) {
 	}
 }
 
+func TestRegex(t *testing.T) {
+	input := `~r"\d+\"" ~r ~5`
+	tests := []struct {
+		Type   token.TokenType
+		Lexeme string
+	}{
+		{token.REGEX, `\d+"`},
+		{token.BITNOT, "~"},
+		{token.IDENTIFIER, "r"},
+		{token.BITNOT, "~"},
+		{token.INTEGER, "5"},
+	}
+
+	lex := New(reader.New([]byte(input)))
+
+	for i, v := range tests {
+		tok := lex.NextToken()
+		if tok.Type != v.Type || tok.Lexeme != v.Lexeme {
+			t.Errorf("Expected [%s %s] but got [%s %s] in line %d", string(v.Type), v.Lexeme, string(tok.Type), tok.Lexeme, i)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	input := `#[1, a] x in s`
+	tests := []struct {
+		Type   token.TokenType
+		Lexeme string
+	}{
+		{token.SETBRACK, "#["},
+		{token.INTEGER, "1"},
+		{token.COMMA, ","},
+		{token.IDENTIFIER, "a"},
+		{token.RBRACK, "]"},
+		{token.IDENTIFIER, "x"},
+		{token.IN, "in"},
+		{token.IDENTIFIER, "s"},
+	}
+
+	lex := New(reader.New([]byte(input)))
+
+	for i, v := range tests {
+		tok := lex.NextToken()
+		if tok.Type != v.Type || tok.Lexeme != v.Lexeme {
+			t.Errorf("Expected [%s %s] but got [%s %s] in line %d", string(v.Type), v.Lexeme, string(tok.Type), tok.Lexeme, i)
+		}
+	}
+}
+
 func TestDelimiters(t *testing.T) {
 	input := `(1, 2, a) ["yes", 5.1, b] [a: b, c: d] a.b a..b`
 	tests := []struct {
@@ -271,3 +320,105 @@ end`
 		}
 	}
 }
+
+func TestComments(t *testing.T) {
+	input := `// first
+let a = 1 // second
+/* third
+*/ a`
+	tests := []struct {
+		Type   token.TokenType
+		Lexeme string
+	}{
+		{token.NEWLINE, "\\n"},
+		{token.LET, "let"},
+		{token.IDENTIFIER, "a"},
+		{token.ASSIGN, "="},
+		{token.INTEGER, "1"},
+		{token.NEWLINE, "\\n"},
+		{token.IDENTIFIER, "a"},
+		{token.EOF, ""},
+	}
+
+	lex := New(reader.New([]byte(input)))
+
+	for i, v := range tests {
+		tok := lex.NextToken()
+		if tok.Type != v.Type || tok.Lexeme != v.Lexeme {
+			t.Errorf("Expected [%s %s] but got [%s %s] in line %d", string(v.Type), v.Lexeme, string(tok.Type), tok.Lexeme, i)
+		}
+	}
+
+	comments := []struct {
+		Lexeme string
+		Row    int
+	}{
+		{"// first", 1},
+		{"// second", 2},
+		{"/* third\n*/", 3},
+	}
+
+	if len(lex.Comments()) != len(comments) {
+		t.Fatalf("Expected %d comments but got %d", len(comments), len(lex.Comments()))
+	}
+
+	for i, v := range comments {
+		comment := lex.Comments()[i]
+		if comment.Lexeme != v.Lexeme || comment.Location.Row != v.Row {
+			t.Errorf("Expected comment %q in row %d but got %q in row %d", v.Lexeme, v.Row, comment.Lexeme, comment.Location.Row)
+		}
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `/// Adds
+///   two numbers.
+let add = 1 /// trailing
+/// Detached.
+
+let sub = 2`
+
+	lex := New(reader.New([]byte(input)))
+	for lex.NextToken().Type != token.EOF {
+	}
+
+	if doc := lex.DocComment(3); doc != "Adds\n  two numbers." {
+		t.Errorf("Expected the doc of add but got %q", doc)
+	}
+
+	if doc := lex.DocComment(4); doc != "" {
+		t.Errorf("Expected trailing comments to be left out but got %q", doc)
+	}
+
+	if doc := lex.DocComment(6); doc != "" {
+		t.Errorf("Expected no doc after a blank line but got %q", doc)
+	}
+}
+
+func TestLocations(t *testing.T) {
+	input := `let x = 1
+  "ab" + 12.5`
+	tests := []struct {
+		Lexeme string
+		Row    int
+		Col    int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"1", 1, 9},
+		{"\\n", 1, 10},
+		{"ab", 2, 3},
+		{"+", 2, 8},
+		{"12.5", 2, 10},
+	}
+
+	lex := New(reader.New([]byte(input)))
+
+	for _, v := range tests {
+		tok := lex.NextToken()
+		if tok.Lexeme != v.Lexeme || tok.Location.Row != v.Row || tok.Location.Col != v.Col {
+			t.Errorf("Expected %q at %d:%d but got %q at %d:%d", v.Lexeme, v.Row, v.Col, tok.Lexeme, tok.Location.Row, tok.Location.Col)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package library
+
+import "testing"
+
+func TestModules(t *testing.T) {
+	names := map[string]bool{}
+	for _, module := range Modules() {
+		if module.Name == "" {
+			t.Errorf("Expected %s to declare a module", module.File)
+		}
+		if names[module.Name] {
+			t.Errorf("Expected module %s to be declared once", module.Name)
+		}
+		names[module.Name] = true
+	}
+
+	for _, name := range []string{"Enum", "String", "Dict", "JSON", "Regex"} {
+		if !names[name] {
+			t.Errorf("Expected module %s to be embedded", name)
+		}
+	}
+
+	if module, ok := Find("Enum"); !ok || module.File != "enum.ari" {
+		t.Errorf("Expected Enum in enum.ari but got %q", module.File)
+	}
+
+	if _, ok := Find("Nope"); ok {
+		t.Errorf("Expected no module named Nope")
+	}
+}
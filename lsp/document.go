@@ -0,0 +1,380 @@
+package lsp
+
+import (
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/checker"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/token"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Keywords offered by completion.
+var keywords = []string{
+	"let", "var", "func", "do", "end", "if", "else", "then", "for", "in",
+	"is", "as", "return", "switch", "case", "default", "break", "continue",
+	"module", "import", "true", "false", "nil",
+}
+
+// A document open in the editor, with what was learned
+// from its last analysis. Names and modules come from the
+// last version that parsed, so they stay around while
+// the code is being written.
+//
+// The lexer counts columns in characters, while the
+// protocol counts them in UTF-16 code units, so positions
+// are converted on the way in and out using the lines of
+// the text.
+type document struct {
+	uri         string
+	path        string
+	text        string
+	lines       []string
+	diagnostics []checker.Diagnostic
+	references  []checker.Reference
+	modules     map[string][]checker.Definition
+	program     *ast.Program
+}
+
+// Create a document and analyse it.
+func newDocument(uri, text string) *document {
+	d := &document{uri: uri, path: uriToPath(uri)}
+	d.update(text)
+
+	return d
+}
+
+// Replace the text and analyse it again.
+func (d *document) update(text string) {
+	d.text = text
+	d.lines = strings.Split(text, "\n")
+
+	check := checker.New()
+	check.CheckSource(d.path, []byte(text))
+
+	d.diagnostics = nil
+	for _, diagnostic := range check.Diagnostics() {
+		if diagnostic.File == d.path {
+			d.diagnostics = append(d.diagnostics, diagnostic)
+		}
+	}
+
+	program := parse(text)
+	if program == nil && d.program != nil {
+		return
+	}
+
+	d.program = program
+	d.modules = check.Modules()
+	d.references = nil
+	for _, reference := range check.References() {
+		if reference.File == d.path {
+			d.references = append(d.references, reference)
+		}
+	}
+}
+
+// The name at a position, if any.
+func (d *document) referenceAt(pos position) (checker.Reference, bool) {
+	column := runeColumn(d.line(pos.Line), pos.Character)
+	for _, reference := range d.references {
+		start := reference.Location.Col - 1
+		if reference.Location.Row-1 == pos.Line && column >= start && column < start+nameLength(reference.Name) {
+			return reference, true
+		}
+	}
+
+	return checker.Reference{}, false
+}
+
+// Diagnostics in the protocol's format.
+func (d *document) protocolDiagnostics() []diagnostic {
+	diagnostics := []diagnostic{}
+	for _, found := range d.diagnostics {
+		start := toPosition(d.lines, found.Location)
+		end := toPosition(d.lines, token.Location{Row: found.Location.Row, Col: found.Location.Col + 1})
+
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    rangeType{Start: start, End: end},
+			Severity: severityError,
+			Source:   "aria",
+			Message:  found.Message,
+		})
+	}
+
+	return diagnostics
+}
+
+// Where the name at a position is declared.
+func (d *document) definition(pos position) interface{} {
+	reference, ok := d.referenceAt(pos)
+	if !ok || reference.Definition.File == "" {
+		return nil
+	}
+
+	definition := reference.Definition
+	return location{
+		URI:   pathToURI(definition.File),
+		Range: nameRange(d.linesOf(definition.File), definition.Location, definition.Name),
+	}
+}
+
+// The signature of the name at a position.
+func (d *document) hover(pos position) interface{} {
+	reference, ok := d.referenceAt(pos)
+	if !ok {
+		return nil
+	}
+
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: "```aria\n" + reference.Definition.Signature() + "\n```"},
+		Range:    nameRange(d.lines, reference.Location, reference.Name),
+	}
+}
+
+// Candidates for the word before a position: members
+// after a module name and a dot, names otherwise.
+func (d *document) completion(pos position) []completionItem {
+	word := d.wordBefore(pos)
+	items := []completionItem{}
+
+	if dot := strings.LastIndex(word, "."); dot != -1 {
+		prefix := word[dot+1:]
+		for _, member := range d.modules[word[:dot]] {
+			if strings.HasPrefix(member.Name, prefix) {
+				items = append(items, completionItem{Label: member.Name, Kind: definitionKind(member), Detail: member.Signature()})
+			}
+		}
+
+		return items
+	}
+
+	seen := map[string]bool{}
+	add := func(item completionItem) {
+		if strings.HasPrefix(item.Label, word) && !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+
+	for _, reference := range d.references {
+		if reference.Definition.Module == "" && reference.Definition.Kind != "module" {
+			add(completionItem{Label: reference.Name, Kind: definitionKind(reference.Definition), Detail: reference.Definition.Signature()})
+		}
+	}
+
+	modules := []string{}
+	for name := range d.modules {
+		modules = append(modules, name)
+	}
+	sort.Strings(modules)
+	for _, name := range modules {
+		add(completionItem{Label: name, Kind: completionModule, Detail: "module " + name})
+	}
+
+	for _, keyword := range keywords {
+		add(completionItem{Label: keyword, Kind: completionKeyword})
+	}
+
+	return items
+}
+
+// The name, or module access, ending at a position.
+func (d *document) wordBefore(pos position) string {
+	text := d.line(pos.Line)
+	line := []rune(text)
+	end := runeColumn(text, pos.Character)
+	if end > len(line) {
+		end = len(line)
+	}
+
+	start := end
+	for start > 0 && (isNameChar(line[start-1]) || line[start-1] == '.') {
+		start--
+	}
+
+	return string(line[start:end])
+}
+
+// Modules with their members, and top-level
+// declarations.
+func (d *document) symbols() []documentSymbol {
+	symbols := []documentSymbol{}
+	if d.program == nil {
+		return symbols
+	}
+
+	for _, statement := range d.program.Statements {
+		expression, ok := statement.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+
+		switch node := expression.Expression.(type) {
+		case *ast.Module:
+			module := d.symbol(node.Name, symbolModule)
+			for _, member := range node.Body.Statements {
+				if member, ok := member.(*ast.ExpressionStatement); ok {
+					if let, ok := member.Expression.(*ast.Let); ok {
+						module.Children = append(module.Children, d.letSymbol(let))
+					}
+				}
+			}
+			symbols = append(symbols, module)
+		case *ast.Let:
+			symbols = append(symbols, d.letSymbol(node))
+		case *ast.Var:
+			symbols = append(symbols, d.symbol(node.Name, symbolVariable))
+		}
+	}
+
+	return symbols
+}
+
+// Symbol of a LET, which is a function or a constant.
+func (d *document) letSymbol(node *ast.Let) documentSymbol {
+	if _, ok := node.Value.(*ast.Function); ok {
+		return d.symbol(node.Name, symbolFunction)
+	}
+
+	return d.symbol(node.Name, symbolConstant)
+}
+
+// Symbol of a name.
+func (d *document) symbol(name *ast.Identifier, kind int) documentSymbol {
+	at := nameRange(d.lines, name.TokenLocation(), name.Value)
+	return documentSymbol{Name: name.Value, Kind: kind, Range: at, SelectionRange: at}
+}
+
+// Completion kind of a definition.
+func definitionKind(definition checker.Definition) int {
+	if definition.Function != nil {
+		return completionFunction
+	}
+
+	return completionVariable
+}
+
+// Parse source code, returning nil on errors.
+func parse(text string) *ast.Program {
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	program := parser.New(lexer.New(reader.New([]byte(text)))).Parse()
+	if reporter.HasErrors() {
+		return nil
+	}
+
+	return program
+}
+
+// A line of the text, or nothing when it's out of range.
+func (d *document) line(index int) string {
+	if index < 0 || index >= len(d.lines) {
+		return ""
+	}
+
+	return d.lines[index]
+}
+
+// Lines of a file, which is either the document or one
+// it imports. Without them, columns are left as they are.
+func (d *document) linesOf(path string) []string {
+	if path == d.path {
+		return d.lines
+	}
+
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	return strings.Split(string(source), "\n")
+}
+
+// Convert a 1-based location to a 0-based position,
+// counting the column in UTF-16 code units.
+func toPosition(lines []string, at token.Location) position {
+	line := ""
+	if at.Row > 0 && at.Row <= len(lines) {
+		line = lines[at.Row-1]
+	}
+
+	return position{Line: at.Row - 1, Character: utf16Column(line, at.Col-1)}
+}
+
+// The range covered by a name.
+func nameRange(lines []string, at token.Location, name string) rangeType {
+	start := toPosition(lines, at)
+	end := start
+	end.Character += len(utf16.Encode([]rune(name)))
+
+	return rangeType{Start: start, End: end}
+}
+
+// UTF-16 column of the character at a column. Past the
+// end of the line, every column counts as one unit.
+func utf16Column(line string, column int) int {
+	units := 0
+	for _, char := range line {
+		if column <= 0 {
+			return units
+		}
+		units += utf16.RuneLen(char)
+		column--
+	}
+
+	return units + column
+}
+
+// Column of the character at a UTF-16 column, the
+// reverse of utf16Column.
+func runeColumn(line string, units int) int {
+	column := 0
+	for _, char := range line {
+		if units <= 0 {
+			return column
+		}
+		units -= utf16.RuneLen(char)
+		column++
+	}
+
+	return column + units
+}
+
+// Length of a name in characters.
+func nameLength(name string) int {
+	return len([]rune(name))
+}
+
+// Check if a character can be part of a name.
+func isNameChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_' || char == '!' || char == '?'
+}
+
+// Path of a file URI.
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+
+	return filepath.FromSlash(parsed.Path)
+}
+
+// File URI of a path.
+func pathToURI(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
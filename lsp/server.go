@@ -0,0 +1,190 @@
+// Package lsp implements the Language Server Protocol
+// over stdio, giving editors diagnostics, go-to-definition,
+// hover, completion and document symbols.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fadion/aria/wire"
+	"io"
+)
+
+// Server answers the requests of an editor.
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+// NewServer initializes a Server reading requests from
+// in and writing responses to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		documents: map[string]*document{},
+	}
+}
+
+// Run serves requests until the client exits. Exiting
+// without a shutdown request first is an error.
+func (s *Server) Run() error {
+	for {
+		body, err := wire.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.fail(nil, parseError, "Invalid JSON message"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exited without shutdown")
+			}
+			return nil
+		}
+
+		result, failure := s.handle(req)
+
+		// Notifications don't get a response.
+		if req.ID == nil {
+			continue
+		}
+
+		if failure != nil {
+			err = s.fail(req.ID, failure.Code, failure.Message)
+		} else {
+			err = wire.Write(s.out, response{JSONRPC: "2.0", ID: req.ID, Result: result})
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Dispatch a request to its handler.
+func (s *Server) handle(req request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:       syncFull,
+				HoverProvider:          true,
+				DefinitionProvider:     true,
+				DocumentSymbolProvider: true,
+				CompletionProvider:     completionOptions{TriggerCharacters: []string{"."}},
+			},
+			ServerInfo: serverInfo{Name: "aria"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		doc := newDocument(params.TextDocument.URI, params.TextDocument.Text)
+		s.documents[doc.uri] = doc
+		return nil, s.publish(doc)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// With full sync, the last change has the whole text.
+		doc.update(params.ContentChanges[len(params.ContentChanges)-1].Text)
+		return nil, s.publish(doc)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		err := wire.Write(s.out, notification{
+			JSONRPC: "2.0",
+			Method:  "textDocument/publishDiagnostics",
+			Params:  publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}},
+		})
+		return nil, failed(err)
+	case "textDocument/definition", "textDocument/hover", "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		switch req.Method {
+		case "textDocument/definition":
+			return doc.definition(params.Position), nil
+		case "textDocument/hover":
+			return doc.hover(params.Position), nil
+		default:
+			return doc.completion(params.Position), nil
+		}
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return doc.symbols(), nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	}
+
+	return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("Method '%s' not found", req.Method)}
+}
+
+// Send the diagnostics of a document.
+func (s *Server) publish(doc *document) *responseError {
+	err := wire.Write(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: doc.uri, Diagnostics: doc.protocolDiagnostics()},
+	})
+
+	return failed(err)
+}
+
+// Send an error response.
+func (s *Server) fail(id *json.RawMessage, code int, message string) error {
+	return wire.Write(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: message}})
+}
+
+// Error for parameters that couldn't be decoded.
+func invalid(err error) *responseError {
+	return &responseError{Code: invalidParams, Message: err.Error()}
+}
+
+// Error for a message that couldn't be written.
+func failed(err error) *responseError {
+	if err == nil {
+		return nil
+	}
+
+	return &responseError{Code: internalError, Message: err.Error()}
+}
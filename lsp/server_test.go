@@ -0,0 +1,202 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fadion/aria/wire"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSource = `module Shapes
+  let area = func (w, h)
+    w * h
+  end
+end
+
+let double = func (x)
+  x * 2
+end
+var total = double(Shapes.area(2, 3))
+Enum.m
+`
+
+// Run the server over a list of messages, returning
+// the responses and notifications it wrote.
+func serve(t *testing.T, messages ...string) []map[string]interface{} {
+	var in bytes.Buffer
+	for _, message := range messages {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	}
+
+	var out bytes.Buffer
+	if err := NewServer(&in, &out).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []map[string]interface{}{}
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := wire.Read(reader)
+		if err != nil {
+			break
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal(body, &result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// JSON of a request at a position of the test document.
+func positionRequest(id int, method string, line, character int) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":{"textDocument":{"uri":"file:///tmp/test.ari"},"position":{"line":%d,"character":%d}}}`, id, method, line, character)
+}
+
+func openRequest(text string) string {
+	encoded, _ := json.Marshal(text)
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///tmp/test.ari","version":1,"text":%s}}}`, encoded)
+}
+
+func TestServer(t *testing.T) {
+	results := serve(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		openRequest(testSource),
+		positionRequest(2, "textDocument/definition", 9, 14),
+		positionRequest(3, "textDocument/hover", 9, 27),
+		positionRequest(4, "textDocument/completion", 10, 6),
+		`{"jsonrpc":"2.0","id":5,"method":"textDocument/documentSymbol","params":{"textDocument":{"uri":"file:///tmp/test.ari"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":7,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	if len(results) != 8 {
+		t.Fatalf("Expected 8 messages but got %d: %v", len(results), results)
+	}
+
+	capabilities := results[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	if capabilities["hoverProvider"] != true || capabilities["definitionProvider"] != true {
+		t.Errorf("Expected hover and definition capabilities but got %v", capabilities)
+	}
+
+	diagnostics := results[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diagnostics) != 1 || !strings.Contains(fmt.Sprint(diagnostics[0]), "Member 'm' in module 'Enum' not found") {
+		t.Errorf("Expected a diagnostic for Enum.m but got %v", diagnostics)
+	}
+
+	definition := results[2]["result"].(map[string]interface{})
+	if definition["uri"] != "file://"+filepath.ToSlash("/tmp/test.ari") || fmt.Sprint(definition["range"]) != "map[end:map[character:10 line:6] start:map[character:4 line:6]]" {
+		t.Errorf("Expected the definition of double but got %v", definition)
+	}
+
+	hover := fmt.Sprint(results[3]["result"])
+	if !strings.Contains(hover, "let Shapes.area = func (w, h)") {
+		t.Errorf("Expected the signature of Shapes.area but got %s", hover)
+	}
+
+	labels := []string{}
+	for _, item := range results[4]["result"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	if strings.Join(labels, ",") != "map,max,maxBy,min,minBy" {
+		t.Errorf("Expected Enum members but got %v", labels)
+	}
+
+	symbols := []string{}
+	for _, item := range results[5]["result"].([]interface{}) {
+		symbol := item.(map[string]interface{})
+		symbols = append(symbols, symbol["name"].(string))
+		if children, ok := symbol["children"].([]interface{}); ok {
+			for _, child := range children {
+				symbols = append(symbols, "  "+child.(map[string]interface{})["name"].(string))
+			}
+		}
+	}
+	if strings.Join(symbols, ",") != "Shapes,  area,double,total" {
+		t.Errorf("Expected the document symbols but got %v", symbols)
+	}
+
+	if results[6]["error"].(map[string]interface{})["code"] != float64(methodNotFound) {
+		t.Errorf("Expected method not found but got %v", results[6])
+	}
+
+	if _, ok := results[7]["result"]; !ok {
+		t.Errorf("Expected a shutdown response but got %v", results[7])
+	}
+}
+
+func TestServerChange(t *testing.T) {
+	results := serve(t,
+		openRequest("let a = 1\na"),
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///tmp/test.ari","version":2},"contentChanges":[{"text":"let a = 1\nb"}]}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///tmp/test.ari","version":3},"contentChanges":[{"text":"let a = (1"}]}}`,
+		positionRequest(1, "textDocument/completion", 0, 0),
+	)
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 messages but got %d: %v", len(results), results)
+	}
+
+	if count := len(results[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})); count != 0 {
+		t.Errorf("Expected no diagnostics but got %d", count)
+	}
+
+	if diagnostics := fmt.Sprint(results[1]["params"]); !strings.Contains(diagnostics, "Identifier 'b' not found") {
+		t.Errorf("Expected 'b' not found but got %s", diagnostics)
+	}
+
+	if count := len(results[2]["params"].(map[string]interface{})["diagnostics"].([]interface{})); count == 0 {
+		t.Errorf("Expected a parse error diagnostic")
+	}
+
+	// Names from the last version that parsed are kept.
+	if completion := fmt.Sprint(results[3]["result"]); !strings.Contains(completion, "label:a]") {
+		t.Errorf("Expected 'a' to be completed but got %s", completion)
+	}
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	in := strings.NewReader("Content-Length: 33\r\n\r\n{\"jsonrpc\":\"2.0\",\"method\":\"exit\"}")
+	var out bytes.Buffer
+
+	if err := NewServer(in, &out).Run(); err == nil {
+		t.Errorf("Expected an error when exiting without shutdown")
+	}
+}
+
+func TestServerUTF16(t *testing.T) {
+	// Each emoji is one character for the lexer but two
+	// UTF-16 code units for the protocol.
+	results := serve(t,
+		openRequest("let double = func (x)\n  x * 2\nend\nlet s = \"😀😀\" + double(1) + y"),
+		positionRequest(1, "textDocument/hover", 3, 18),
+		positionRequest(2, "textDocument/completion", 3, 22),
+	)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 messages but got %d: %v", len(results), results)
+	}
+
+	diagnostics := results[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diagnostics) != 1 || fmt.Sprint(diagnostics[0].(map[string]interface{})["range"]) != "map[end:map[character:30 line:3] start:map[character:29 line:3]]" {
+		t.Errorf("Expected a diagnostic for y at UTF-16 column 29 but got %v", diagnostics)
+	}
+
+	hover := results[1]["result"].(map[string]interface{})
+	if fmt.Sprint(hover["range"]) != "map[end:map[character:23 line:3] start:map[character:17 line:3]]" {
+		t.Errorf("Expected the hover range of double but got %v", hover["range"])
+	}
+
+	if completion := fmt.Sprint(results[2]["result"]); !strings.Contains(completion, "label:double]") {
+		t.Errorf("Expected 'double' to be completed but got %s", completion)
+	}
+}
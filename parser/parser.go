@@ -3,10 +3,13 @@ package parser
 import (
 	"fmt"
 	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/decimal"
 	"github.com/fadion/aria/lexer"
 	"github.com/fadion/aria/reporter"
 	"github.com/fadion/aria/token"
+	"math/big"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -42,10 +45,13 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefix(token.FUNCTION, p.parseFunction)
 	p.prefix(token.IMPORT, p.parseImport)
 	p.prefix(token.LBRACK, p.parseArrayOrDictionary)
+	p.prefix(token.SETBRACK, p.parseSet)
 	p.prefix(token.IDENTIFIER, p.parseIdentifier)
 	p.prefix(token.INTEGER, p.parseInteger)
 	p.prefix(token.FLOAT, p.parseFloat)
+	p.prefix(token.DECIMAL, p.parseDecimal)
 	p.prefix(token.STRING, p.parseString)
+	p.prefix(token.REGEX, p.parseRegex)
 	p.prefix(token.BOOLEAN, p.parseBoolean)
 	p.prefix(token.NIL, p.parseNil)
 	p.prefix(token.UNDERSCORE, p.parsePlaceholder)
@@ -68,6 +74,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.infix(token.ARROW, p.parseArrowFunction)
 	p.infix(token.QUESTION, p.parseTernary)
 	p.infix(token.IS, p.parseIs)
+	p.infix(token.IN, p.parseInfix)
 	p.infix(token.AS, p.parseAs)
 	p.infix(token.RANGE, p.parseInfix)
 	p.infix(token.PLUS, p.parseInfix)
@@ -136,6 +143,16 @@ func (p *Parser) infix(tokenType token.TokenType, fn infixParseFn) {
 	p.infixFunctions[tokenType] = fn
 }
 
+// Precedence returns the binding power of an operator
+// token, or LOWEST if it's not an operator.
+func Precedence(tokenType token.TokenType) int {
+	if p, ok := precedences[tokenType]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
 // Get the precedence of the current token.
 func (p *Parser) precedence() int {
 	if p, ok := precedences[p.token.Type]; ok {
@@ -182,8 +199,6 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseBreak()
 	case token.CONTINUE:
 		return p.parseContinue()
-	case token.COMMENT: // Ignore comments.
-		return nil
 	case token.NEWLINE: // Ignore newlines.
 		return nil
 	default:
@@ -193,7 +208,7 @@ func (p *Parser) parseStatement() ast.Statement {
 
 // let IDENT = EXPRESSION
 func (p *Parser) parseLet() ast.Expression {
-	expression := &ast.Let{Token: p.token}
+	expression := &ast.Let{Token: p.token, Doc: p.lex.DocComment(p.token.Location.Row)}
 
 	// Check for identifier.
 	if !p.peekMatch(token.IDENTIFIER) {
@@ -245,7 +260,7 @@ func (p *Parser) parseVar() ast.Expression {
 
 // module IDENT BODY
 func (p *Parser) parseModule() ast.Expression {
-	expression := &ast.Module{Token: p.token}
+	expression := &ast.Module{Token: p.token, Doc: p.lex.DocComment(p.token.Location.Row)}
 
 	// Check for an identifier.
 	if !p.peekMatch(token.IDENTIFIER) {
@@ -301,31 +316,40 @@ func (p *Parser) parseInteger() ast.Expression {
 	literal := &ast.Integer{Token: p.token}
 	lexeme := p.token.Lexeme
 
-	var value int64
-	var err error
+	// Base 0 lets Go infer the base of decimal literals,
+	// as it always has.
+	digits := lexeme
+	base := 0
 
 	if strings.HasPrefix(lexeme, "0b") {
-		// Binary: 0b1010. Native Go type doesn't use the "0b" prefix.
-		value, err = strconv.ParseInt(lexeme[2:], 2, 64)
+		// Binary: 0b1010.
+		digits, base = lexeme[2:], 2
 	} else if strings.HasPrefix(lexeme, "0x") {
 		// Hexadecimal: 0xff.
-		value, err = strconv.ParseInt(lexeme, 0, 64)
+		digits, base = lexeme[2:], 16
 	} else if strings.HasPrefix(lexeme, "0o") {
-		// Octal: 0o27. Native Go type uses just a "0" as prefix.
-		value, err = strconv.ParseInt(lexeme[:1]+lexeme[2:], 0, 64)
-	} else {
-		// Normal integer.
-		value, err = strconv.ParseInt(lexeme, 0, 64)
+		// Octal: 0o27.
+		digits, base = lexeme[2:], 8
 	}
 
-	if err != nil {
-		p.reportError(fmt.Sprintf("Couldn't parse %s as Integer", p.token.Lexeme))
-		return nil
+	value, err := strconv.ParseInt(digits, base, 64)
+	if err == nil {
+		literal.Value = value
+		return literal
 	}
 
-	literal.Value = value
+	// Literals too large for 64 bits are kept
+	// with arbitrary precision.
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		if bigValue, ok := new(big.Int).SetString(digits, base); ok {
+			literal.Value = value
+			literal.Big = bigValue
+			return literal
+		}
+	}
 
-	return literal
+	p.reportError(fmt.Sprintf("Couldn't parse %s as Integer", p.token.Lexeme))
+	return nil
 }
 
 // Floating point literal.
@@ -344,11 +368,33 @@ func (p *Parser) parseFloat() ast.Expression {
 	return literal
 }
 
+// Decimal literal.
+func (p *Parser) parseDecimal() ast.Expression {
+	value, err := decimal.Parse(p.token.Lexeme)
+	if err != nil {
+		p.reportError(fmt.Sprintf("Couldn't parse %s as Decimal", p.token.Lexeme))
+		return nil
+	}
+
+	return &ast.Decimal{Token: p.token, Value: value}
+}
+
 // String literal.
 func (p *Parser) parseString() ast.Expression {
 	return &ast.String{Token: p.token, Value: p.token.Lexeme}
 }
 
+// Regex literal, compiled to catch invalid
+// patterns before running.
+func (p *Parser) parseRegex() ast.Expression {
+	if _, err := regexp.Compile(p.token.Lexeme); err != nil {
+		p.reportError(fmt.Sprintf("Invalid regex: %s", err))
+		return nil
+	}
+
+	return &ast.Regex{Token: p.token, Value: p.token.Lexeme}
+}
+
 // Boolean literal.
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.token, Value: p.token.Lexeme == "true"}
@@ -397,6 +443,8 @@ func (p *Parser) parseIf() ast.Expression {
 		return nil
 	}
 
+	block.End = p.token
+
 	expression.Then = block
 
 	// Parse the optional ELSE block.
@@ -510,6 +558,8 @@ func (p *Parser) parseSwitchCase() *ast.BlockStatement {
 		}
 	}
 
+	block.End = p.peekToken
+
 	return block
 }
 
@@ -689,6 +739,7 @@ func (p *Parser) parseImport() ast.Expression {
 
 // Find out if it's an array or a dictionary.
 func (p *Parser) parseArrayOrDictionary() ast.Expression {
+	open := p.token
 	p.advance()
 	list := []ast.Expression{}
 	isDict := false
@@ -715,8 +766,8 @@ func (p *Parser) parseArrayOrDictionary() ast.Expression {
 
 	if !isDict {
 		// Build an array.
-		expression := &ast.Array{Token: p.token}
-		expression.List = &ast.ExpressionList{Elements: list}
+		expression := &ast.Array{Token: open}
+		expression.List = &ast.ExpressionList{Token: open, Elements: list}
 		return expression
 	}
 
@@ -727,8 +778,8 @@ func (p *Parser) parseArrayOrDictionary() ast.Expression {
 		return nil
 	}
 
-	expression := &ast.Dictionary{Token: p.token}
-	expression.Pairs = map[ast.Expression]ast.Expression{}
+	expression := &ast.Dictionary{Token: open}
+	expression.Pairs = []*ast.DictionaryPair{}
 	// Build a dictionary treating every even
 	// element as the key and the next as value.
 	for i, v := range list {
@@ -739,13 +790,45 @@ func (p *Parser) parseArrayOrDictionary() ast.Expression {
 				return nil
 			}
 
-			expression.Pairs[v] = list[i+1]
+			expression.Pairs = append(expression.Pairs, &ast.DictionaryPair{Key: v, Value: list[i+1]})
 		}
 	}
 
 	return expression
 }
 
+// #[EXPRESSION, EXPRESSION]
+func (p *Parser) parseSet() ast.Expression {
+	expression := &ast.Set{Token: p.token}
+	list := []ast.Expression{}
+	p.advance()
+
+	// Consume tokens until a closing right bracket.
+	for !p.match(token.RBRACK) {
+		switch {
+		case p.match(token.NEWLINE, token.EOF): // Error.
+			p.reportError("Missing closing ']' in set")
+			return nil
+		case p.match(token.FATARROW):
+			p.reportError("Set expects elements, not Key:Value pairs")
+			return nil
+		case p.match(token.COMMA): // Ignore commas.
+		default:
+			element := p.parseExpression(LOWEST)
+			if element == nil {
+				return nil
+			}
+
+			list = append(list, element)
+		}
+		p.advance()
+	}
+
+	expression.List = &ast.ExpressionList{Token: expression.Token, Elements: list}
+
+	return expression
+}
+
 // return EXPRESSION
 func (p *Parser) parseReturn() *ast.Return {
 	statement := &ast.Return{Token: p.token}
@@ -787,7 +870,40 @@ func (p *Parser) parseSubscript(left ast.Expression) ast.Expression {
 		return expression
 	}
 
-	expression.Index = p.parseExpression(LOWEST)
+	// A slice without a start: array[..2].
+	if p.match(token.RANGE) {
+		expression.Index = p.parseSlice(nil)
+		if expression.Index == nil {
+			return nil
+		}
+
+		p.advance()
+		return expression
+	}
+
+	// The index is parsed up to a possible range operator,
+	// so an open slice like array[2..] doesn't get parsed
+	// as an incomplete range. Anything else continues as
+	// a normal expression.
+	prefix := p.prefixFunctions[p.token.Type]
+	if prefix == nil {
+		p.reportError(fmt.Sprintf("Unexpected expression '%s'", p.token.Lexeme))
+		return nil
+	}
+
+	index := p.parseInfixChain(prefix(), RANGE)
+	if p.peekMatch(token.RANGE) {
+		p.advance()
+		expression.Index = p.parseSlice(index)
+		if expression.Index == nil {
+			return nil
+		}
+
+		p.advance()
+		return expression
+	}
+
+	expression.Index = p.parseInfixChain(index, LOWEST)
 
 	// Missing closing right bracket.
 	if !p.peekMatch(token.RBRACK) {
@@ -800,6 +916,40 @@ func (p *Parser) parseSubscript(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// START..END..STEP inside a subscript, where
+// every part is optional.
+func (p *Parser) parseSlice(start ast.Expression) ast.Expression {
+	expression := &ast.Slice{Token: p.token, Start: start}
+
+	// End is parsed with the range precedence, so it stops
+	// before the optional step.
+	if !p.peekMatch(token.RBRACK, token.RANGE) {
+		p.advance()
+		expression.End = p.parseExpression(RANGE)
+		if expression.End == nil {
+			return nil
+		}
+	}
+
+	if p.peekMatch(token.RANGE) {
+		p.advance()
+		p.advance()
+		expression.Step = p.parseExpression(LOWEST)
+		if expression.Step == nil {
+			p.reportError("Missing step in slice expression")
+			return nil
+		}
+	}
+
+	// Missing closing right bracket.
+	if !p.peekMatch(token.RBRACK) {
+		p.reportError("Missing closing ] in slice expression")
+		return nil
+	}
+
+	return expression
+}
+
 // IDENT() |> IDENT()
 func (p *Parser) parsePipe(left ast.Expression) ast.Expression {
 	expression := &ast.Pipe{
@@ -1055,8 +1205,13 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.reportError(fmt.Sprintf("Unexpected expression '%s'", p.token.Lexeme))
 		return nil
 	}
-	left := prefix()
 
+	return p.parseInfixChain(prefix(), precedence)
+}
+
+// Continue parsing an expression from an already
+// parsed left side.
+func (p *Parser) parseInfixChain(left ast.Expression, precedence int) ast.Expression {
 	// Run the infix function until the next token has
 	// a higher precedence.
 	for precedence < p.peekPrecedence() {
@@ -1074,6 +1229,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 // Parse a group expression of expressions.
 func (p *Parser) parseGroup() ast.Expression {
+	open := p.token
 	p.advance()
 	expression := p.parseExpression(LOWEST)
 
@@ -1081,7 +1237,7 @@ func (p *Parser) parseGroup() ast.Expression {
 	if p.peekMatch(token.COMMA) {
 		p.advance()
 
-		list := &ast.ExpressionList{}
+		list := &ast.ExpressionList{Token: open}
 		list.Elements = []ast.Expression{expression}
 		rest := p.parseDelimited(token.COMMA, token.RPAREN)
 
@@ -1165,6 +1321,8 @@ func (p *Parser) parseBlockBody() *ast.BlockStatement {
 		p.advance()
 	}
 
+	block.End = p.token
+
 	return block
 }
 
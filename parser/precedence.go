@@ -47,6 +47,7 @@ var precedences = map[token.TokenType]int{
 	token.LTE:  COMPARISON,
 	token.GTE:  COMPARISON,
 	token.GT:   COMPARISON,
+	token.IN:   COMPARISON,
 
 	token.OR:  BOOLEAN,
 	token.AND: BOOLEAN,
@@ -0,0 +1,208 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Field numbers of pprof's profile.proto.
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+	profileDefaultType   = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// WriteProfile writes the samples as a gzipped pprof
+// profile, readable by `go tool pprof`. Each sample
+// has a count, the time it stands for and the bytes
+// allocated since the previous one.
+func (p *Profiler) WriteProfile(out io.Writer) error {
+	strings := newStringTable()
+	profile := &message{}
+
+	for _, kind := range [][2]string{{"samples", "count"}, {"cpu", "nanoseconds"}, {"alloc_space", "bytes"}} {
+		valueType := &message{}
+		valueType.int(valueTypeType, strings.index(kind[0]))
+		valueType.int(valueTypeUnit, strings.index(kind[1]))
+		profile.message(profileSampleType, valueType)
+	}
+
+	functions := map[function]uint64{}
+	locations := map[location]uint64{}
+	functionMessages := []*message{}
+	locationMessages := []*message{}
+
+	for _, key := range p.order {
+		s := p.samples[key]
+
+		// pprof lists locations from the innermost call.
+		ids := []uint64{}
+		for index := len(s.stack) - 1; index >= 0; index-- {
+			at := s.stack[index]
+
+			if _, ok := functions[at.function]; !ok {
+				id := uint64(len(functions) + 1)
+				functions[at.function] = id
+
+				fn := &message{}
+				fn.uint(functionID, id)
+				fn.int(functionName, strings.index(at.function.name))
+				fn.int(functionSystemName, strings.index(at.function.name))
+				fn.int(functionFilename, strings.index(at.function.file))
+				fn.int(functionStartLine, int64(at.function.line))
+				functionMessages = append(functionMessages, fn)
+			}
+
+			if _, ok := locations[at]; !ok {
+				id := uint64(len(locations) + 1)
+				locations[at] = id
+
+				line := &message{}
+				line.uint(lineFunctionID, functions[at.function])
+				line.int(lineLine, int64(at.line))
+
+				loc := &message{}
+				loc.uint(locationID, id)
+				loc.message(locationLine, line)
+				locationMessages = append(locationMessages, loc)
+			}
+
+			ids = append(ids, locations[at])
+		}
+
+		entry := &message{}
+		entry.packed(sampleLocationID, ids)
+		entry.packed(sampleValue, []uint64{uint64(s.count), uint64(s.count * int64(p.period)), uint64(s.bytes)})
+		profile.message(profileSample, entry)
+	}
+
+	for _, loc := range locationMessages {
+		profile.message(profileLocation, loc)
+	}
+	for _, fn := range functionMessages {
+		profile.message(profileFunction, fn)
+	}
+
+	profile.int(profileTimeNanos, p.started.UnixNano())
+	profile.int(profileDurationNanos, int64(p.duration))
+
+	periodType := &message{}
+	periodType.int(valueTypeType, strings.index("cpu"))
+	periodType.int(valueTypeUnit, strings.index("nanoseconds"))
+	profile.message(profilePeriodType, periodType)
+	profile.int(profilePeriod, int64(p.period))
+	profile.int(profileDefaultType, strings.index("cpu"))
+
+	// Strings go last, once every other field
+	// has added its own.
+	for _, value := range strings.values {
+		profile.string(profileStringTable, value)
+	}
+
+	zipped := gzip.NewWriter(out)
+	if _, err := zipped.Write(profile.Bytes()); err != nil {
+		return err
+	}
+
+	return zipped.Close()
+}
+
+// Strings of a profile, referenced by index. The
+// first one is always empty.
+type stringTable struct {
+	values  []string
+	indexes map[string]int64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{values: []string{""}, indexes: map[string]int64{"": 0}}
+}
+
+func (t *stringTable) index(value string) int64 {
+	if index, ok := t.indexes[value]; ok {
+		return index
+	}
+
+	index := int64(len(t.values))
+	t.values = append(t.values, value)
+	t.indexes[value] = index
+	return index
+}
+
+// A protocol buffer message, encoded as its
+// fields are added.
+type message struct {
+	bytes.Buffer
+}
+
+func (m *message) varint(value uint64) {
+	for value >= 0x80 {
+		m.WriteByte(byte(value) | 0x80)
+		value >>= 7
+	}
+	m.WriteByte(byte(value))
+}
+
+func (m *message) key(field int, wireType int) {
+	m.varint(uint64(field<<3 | wireType))
+}
+
+// Zero values are left out, as protobuf does.
+func (m *message) uint(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+
+	m.key(field, 0)
+	m.varint(value)
+}
+
+func (m *message) int(field int, value int64) {
+	m.uint(field, uint64(value))
+}
+
+func (m *message) string(field int, value string) {
+	m.key(field, 2)
+	m.varint(uint64(len(value)))
+	m.WriteString(value)
+}
+
+func (m *message) message(field int, value *message) {
+	m.key(field, 2)
+	m.varint(uint64(value.Len()))
+	m.Write(value.Bytes())
+}
+
+func (m *message) packed(field int, values []uint64) {
+	packed := &message{}
+	for _, value := range values {
+		packed.varint(value)
+	}
+	m.message(field, packed)
+}
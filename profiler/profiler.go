@@ -0,0 +1,225 @@
+// Package profiler samples the Aria-level call stack of
+// a running program, writing pprof profiles and flat
+// reports of function calls.
+package profiler
+
+import (
+	"fmt"
+	"github.com/fadion/aria/interpreter"
+	"io"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPeriod is how often the call stack is
+// sampled, like Go's own CPU profiler.
+const DefaultPeriod = 10 * time.Millisecond
+
+// Profiler samples the call stack of an Aria program
+// and counts its function calls. Samples are taken
+// by the interpreter between statements, whenever a
+// period has gone by.
+type Profiler struct {
+	ticks     int64 // First, to be aligned for atomic access.
+	runner    *interpreter.Interpreter
+	period    time.Duration
+	done      chan bool
+	started   time.Time
+	duration  time.Duration
+	allocated uint64
+	samples   map[string]*sample
+	order     []string
+	calls     map[function]*Call
+	active    map[function]int
+	entered   []time.Time
+}
+
+// An Aria function, identified by where it was
+// declared.
+type function struct {
+	name string
+	file string
+	line int
+}
+
+// A line of a function on the call stack.
+type location struct {
+	function function
+	line     int
+}
+
+// Call stacks seen when sampling, innermost last,
+// with the periods and memory attributed to them.
+type sample struct {
+	stack []location
+	count int64
+	bytes int64
+}
+
+// Call is how many times a function was called and
+// the time spent in it, including the calls it made.
+type Call struct {
+	Name       string
+	File       string
+	Line       int
+	Count      int
+	Cumulative time.Duration
+}
+
+// New initializes a Profiler for an interpreter.
+func New(runner *interpreter.Interpreter) *Profiler {
+	return &Profiler{
+		runner:  runner,
+		period:  DefaultPeriod,
+		samples: map[string]*sample{},
+		calls:   map[function]*Call{},
+		active:  map[function]int{},
+	}
+}
+
+// SetPeriod changes how often samples are taken.
+func (p *Profiler) SetPeriod(period time.Duration) {
+	p.period = period
+}
+
+// Start traces the interpreter and starts sampling.
+func (p *Profiler) Start() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	p.allocated = stats.TotalAlloc
+
+	p.started = time.Now()
+	p.done = make(chan bool)
+	p.runner.SetTracer(p)
+
+	ticker := time.NewTicker(p.period)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				atomic.AddInt64(&p.ticks, 1)
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends sampling. The last statement gets the
+// time left since the previous sample.
+func (p *Profiler) Stop() {
+	close(p.done)
+	p.take()
+	p.runner.SetTracer(nil)
+	p.duration = time.Since(p.started)
+}
+
+// Step is part of interpreter.Tracer.
+func (p *Profiler) Step() {
+	p.take()
+}
+
+// Enter is part of interpreter.Tracer.
+func (p *Profiler) Enter(frame interpreter.Frame) {
+	p.take()
+
+	fn := functionOf(frame)
+	call, ok := p.calls[fn]
+	if !ok {
+		call = &Call{Name: fn.name, File: fn.file, Line: fn.line}
+		p.calls[fn] = call
+	}
+
+	call.Count++
+	p.active[fn]++
+	p.entered = append(p.entered, time.Now())
+}
+
+// Leave is part of interpreter.Tracer.
+func (p *Profiler) Leave(frame interpreter.Frame) {
+	p.take()
+
+	if len(p.entered) == 0 {
+		return
+	}
+
+	entered := p.entered[len(p.entered)-1]
+	p.entered = p.entered[:len(p.entered)-1]
+
+	// Recursive calls are already timed by the
+	// outermost one.
+	fn := functionOf(frame)
+	p.active[fn]--
+	if p.active[fn] == 0 {
+		p.calls[fn].Cumulative += time.Since(entered)
+	}
+}
+
+// Calls returns the functions that were called,
+// by cumulative time.
+func (p *Profiler) Calls() []Call {
+	calls := []Call{}
+	for _, call := range p.calls {
+		calls = append(calls, *call)
+	}
+
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Cumulative != calls[j].Cumulative {
+			return calls[i].Cumulative > calls[j].Cumulative
+		}
+		return calls[i].Name < calls[j].Name
+	})
+
+	return calls
+}
+
+// WriteCalls writes a flat report of the calls.
+func (p *Profiler) WriteCalls(out io.Writer) {
+	fmt.Fprintf(out, "%8s  %12s  %s\n", "calls", "cumulative", "function")
+	for _, call := range p.Calls() {
+		fmt.Fprintf(out, "%8d  %12s  %s (%s:%d)\n", call.Count, call.Cumulative.Round(time.Microsecond), call.Name, call.File, call.Line)
+	}
+}
+
+// Record the call stack if any period went by
+// since the last sample.
+func (p *Profiler) take() {
+	ticks := atomic.SwapInt64(&p.ticks, 0)
+	if ticks == 0 {
+		return
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	allocated := int64(stats.TotalAlloc - p.allocated)
+	p.allocated = stats.TotalAlloc
+
+	stack := []location{}
+	key := ""
+	for _, frame := range p.runner.Frames() {
+		at := location{function: functionOf(frame), line: frame.Location.Row}
+		stack = append(stack, at)
+		key += fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00", at.function.name, at.function.file, at.function.line, at.line)
+	}
+
+	if len(stack) == 0 {
+		return
+	}
+
+	s, ok := p.samples[key]
+	if !ok {
+		s = &sample{stack: stack}
+		p.samples[key] = s
+		p.order = append(p.order, key)
+	}
+
+	s.count += ticks
+	s.bytes += allocated
+}
+
+func functionOf(frame interpreter.Frame) function {
+	return function{name: frame.Name, file: frame.File, line: frame.Declared.Row}
+}
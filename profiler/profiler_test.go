@@ -0,0 +1,127 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/fadion/aria/interpreter"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSource = `let fib = func (n)
+  if n < 2
+    n
+  else
+    fib(n - 1) + fib(n - 2)
+  end
+end
+let square = func (x)
+  x * x
+end
+fib(10)
+Enum.map([1, 2, 3], square)`
+
+// Run the test source with a profiler that takes
+// a single sample, when it's stopped.
+func profile(t *testing.T) *Profiler {
+	lex := lexer.New(reader.New([]byte(testSource)))
+	parse := parser.New(lex)
+	program := parse.Parse()
+
+	runner := interpreter.New()
+	runner.SetFile("test.ari")
+
+	prof := New(runner)
+	prof.SetPeriod(time.Hour)
+	prof.Start()
+	runner.Interpret(program, interpreter.NewScope())
+	prof.ticks = 1
+	prof.Stop()
+
+	if reporter.HasErrors() {
+		t.Fatalf("Unexpected errors: %v", reporter.GetErrors())
+	}
+
+	return prof
+}
+
+func TestCalls(t *testing.T) {
+	calls := map[string]Call{}
+	for _, call := range profile(t).Calls() {
+		calls[call.Name] = call
+	}
+
+	if fib := calls["fib"]; fib.Count != 177 || fib.File != "test.ari" || fib.Line != 1 {
+		t.Errorf("Expected 177 calls of fib at test.ari:1 but got %+v", fib)
+	}
+
+	// Functions are named as they're called.
+	if fn := calls["fn"]; fn.Count != 3 || fn.Line != 8 {
+		t.Errorf("Expected 3 calls of square but got %+v", fn)
+	}
+
+	if mapped := calls["Enum.map"]; mapped.Count != 1 || mapped.File != interpreter.LibraryFile || mapped.Cumulative < calls["fn"].Cumulative {
+		t.Errorf("Expected Enum.map to include its calls but got %+v", mapped)
+	}
+}
+
+func TestWriteCalls(t *testing.T) {
+	var out bytes.Buffer
+	profile(t).WriteCalls(&out)
+
+	lines := strings.Split(out.String(), "\n")
+	if !strings.Contains(lines[0], "calls") || !strings.Contains(lines[0], "cumulative") {
+		t.Errorf("Expected a header but got %q", lines[0])
+	}
+
+	if !strings.Contains(out.String(), "177  ") || !strings.Contains(out.String(), "fib (test.ari:1)") {
+		t.Errorf("Expected a line for fib but got %q", out.String())
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	prof := profile(t)
+	if len(prof.samples) != 1 {
+		t.Fatalf("Expected a single sample but got %d", len(prof.samples))
+	}
+
+	var out bytes.Buffer
+	if err := prof.WriteProfile(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	zipped, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("Expected a gzipped profile: %v", err)
+	}
+
+	contents, err := ioutil.ReadAll(zipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"samples", "cpu", "nanoseconds", "alloc_space", "main", "test.ari"} {
+		if !bytes.Contains(contents, []byte(expected)) {
+			t.Errorf("Expected %q in the profile", expected)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	m := &message{}
+	m.uint(1, 150)
+	m.uint(2, 0)
+	m.string(3, "hi")
+	m.packed(4, []uint64{1, 300})
+
+	expected := []byte{0x08, 0x96, 0x01, 0x1a, 0x02, 'h', 'i', 0x22, 0x03, 0x01, 0xac, 0x02}
+	if !bytes.Equal(m.Bytes(), expected) {
+		t.Errorf("Expected % x but got % x", expected, m.Bytes())
+	}
+}
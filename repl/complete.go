@@ -0,0 +1,64 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+)
+
+// Keywords offered by completion.
+var keywords = []string{
+	"let", "var", "func", "do", "end", "if", "else", "then", "for", "in",
+	"is", "as", "return", "switch", "case", "default", "break", "continue",
+	"module", "import", "true", "false", "nil",
+}
+
+// Complete returns the candidates for a word: names and
+// modules, or the members of a module when the word
+// is like "Module.mem".
+func Complete(word string, names []string, modules map[string][]string) []string {
+	candidates := []string{}
+	seen := map[string]bool{}
+
+	add := func(candidate string) {
+		if strings.HasPrefix(candidate, word) && !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if dot := strings.Index(word, "."); dot != -1 {
+		for _, member := range modules[word[:dot]] {
+			add(word[:dot] + "." + member)
+		}
+	} else {
+		for _, name := range names {
+			add(name)
+		}
+		for module := range modules {
+			add(module)
+		}
+		for _, keyword := range keywords {
+			add(keyword)
+		}
+	}
+
+	sort.Strings(candidates)
+
+	return candidates
+}
+
+// The longest prefix shared by all the candidates.
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,261 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned when a line is
+// abandoned with CTRL+C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Key codes of the control characters.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Editor reads lines from a terminal in raw mode, with
+// cursor movement, history and completion.
+type Editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  *History
+	complete func(word string) []string
+
+	prompt  string
+	line    []rune
+	cursor  int
+	recall  int    // History entry being shown.
+	pending string // Line being written before moving in history.
+}
+
+// NewEditor initializes an Editor. The terminal must
+// already be in raw mode.
+func NewEditor(in io.Reader, out io.Writer, history *History, complete func(word string) []string) *Editor {
+	return &Editor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		history:  history,
+		complete: complete,
+	}
+}
+
+// ReadLine reads a line, returning io.EOF on CTRL+D with
+// an empty line and ErrInterrupted on CTRL+C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	e.prompt = prompt
+	e.line = []rune{}
+	e.cursor = 0
+	e.recall = len(e.history.Entries())
+	e.refresh()
+
+	for {
+		char, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch char {
+		case keyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.line), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteForward()
+		case keyBackspace, keyDelete:
+			e.deleteBackward()
+		case keyCtrlA:
+			e.cursor = 0
+		case keyCtrlE:
+			e.cursor = len(e.line)
+		case keyCtrlB:
+			e.moveCursor(-1)
+		case keyCtrlF:
+			e.moveCursor(1)
+		case keyCtrlK:
+			e.line = e.line[:e.cursor]
+		case keyCtrlU:
+			e.line = e.line[e.cursor:]
+			e.cursor = 0
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			e.recallHistory(-1)
+		case keyCtrlN:
+			e.recallHistory(1)
+		case keyTab:
+			e.completeWord()
+		case keyEscape:
+			e.escapeSequence()
+		default:
+			if unicode.IsPrint(char) {
+				e.insert(char)
+			}
+		}
+
+		e.refresh()
+	}
+}
+
+// Handle the escape sequences sent by arrow keys
+// and the like.
+func (e *Editor) escapeSequence() {
+	next, _, err := e.in.ReadRune()
+	if err != nil || next != '[' && next != 'O' {
+		return
+	}
+
+	code, _, err := e.in.ReadRune()
+	if err != nil {
+		return
+	}
+
+	switch code {
+	case 'A':
+		e.recallHistory(-1)
+	case 'B':
+		e.recallHistory(1)
+	case 'C':
+		e.moveCursor(1)
+	case 'D':
+		e.moveCursor(-1)
+	case 'H':
+		e.cursor = 0
+	case 'F':
+		e.cursor = len(e.line)
+	case '1', '3', '4', '7', '8':
+		// Sequences like ESC [ 3 ~ end with a tilde.
+		if tilde, _, err := e.in.ReadRune(); err != nil || tilde != '~' {
+			return
+		}
+		switch code {
+		case '1', '7':
+			e.cursor = 0
+		case '4', '8':
+			e.cursor = len(e.line)
+		case '3':
+			e.deleteForward()
+		}
+	}
+}
+
+// Insert a character at the cursor.
+func (e *Editor) insert(chars ...rune) {
+	line := append([]rune{}, e.line[:e.cursor]...)
+	line = append(line, chars...)
+	e.line = append(line, e.line[e.cursor:]...)
+	e.cursor += len(chars)
+}
+
+// Delete the character before the cursor.
+func (e *Editor) deleteBackward() {
+	if e.cursor > 0 {
+		e.line = append(e.line[:e.cursor-1], e.line[e.cursor:]...)
+		e.cursor--
+	}
+}
+
+// Delete the character under the cursor.
+func (e *Editor) deleteForward() {
+	if e.cursor < len(e.line) {
+		e.line = append(e.line[:e.cursor], e.line[e.cursor+1:]...)
+	}
+}
+
+// Move the cursor within the line.
+func (e *Editor) moveCursor(offset int) {
+	cursor := e.cursor + offset
+	if cursor >= 0 && cursor <= len(e.line) {
+		e.cursor = cursor
+	}
+}
+
+// Replace the line with an older or newer entry of the
+// history, keeping what was being written for when
+// coming back.
+func (e *Editor) recallHistory(offset int) {
+	entries := e.history.Entries()
+	recall := e.recall + offset
+	if recall < 0 || recall > len(entries) {
+		return
+	}
+
+	if e.recall == len(entries) {
+		e.pending = string(e.line)
+	}
+	e.recall = recall
+
+	if recall == len(entries) {
+		e.line = []rune(e.pending)
+	} else {
+		e.line = []rune(entries[recall])
+	}
+	e.cursor = len(e.line)
+}
+
+// Complete the word before the cursor. A single candidate
+// replaces it, while more of them are listed after
+// completing what they have in common.
+func (e *Editor) completeWord() {
+	start := e.cursor
+	for start > 0 && (isNameChar(e.line[start-1]) || e.line[start-1] == '.') {
+		start--
+	}
+
+	word := string(e.line[start:e.cursor])
+	if word == "" {
+		return
+	}
+
+	candidates := e.complete(word)
+	switch {
+	case len(candidates) == 0:
+		fmt.Fprint(e.out, "\a")
+	case len(candidates) == 1:
+		e.insert([]rune(candidates[0][len(word):])...)
+	default:
+		if prefix := commonPrefix(candidates); len(prefix) > len(word) {
+			e.insert([]rune(prefix[len(word):])...)
+			return
+		}
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// Redraw the line and put the cursor in place.
+func (e *Editor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.line))
+	if back := len(e.line) - e.cursor; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// Check if a character can be part of a name.
+func isNameChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_' || char == '!' || char == '?'
+}
@@ -0,0 +1,83 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Most entries kept in the history file.
+const historyLimit = 1000
+
+// History of entered lines, optionally persisted
+// to a file with an entry per line.
+type History struct {
+	entries []string
+	file    string
+}
+
+// NewHistory loads the history from a file. A missing
+// file is just an empty history.
+func NewHistory(file string) *History {
+	h := &History{file: file}
+	if file == "" {
+		return h
+	}
+
+	handle, err := os.Open(file)
+	if err != nil {
+		return h
+	}
+	defer handle.Close()
+
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+
+	return h
+}
+
+// Add an entry to the history, skipping empty ones and
+// those repeating the previous entry.
+func (h *History) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+}
+
+// Entries returns the history, oldest first.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Save writes the history to its file.
+func (h *History) Save() error {
+	if h.file == "" {
+		return nil
+	}
+
+	handle, err := os.Create(h.file)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	writer := bufio.NewWriter(handle)
+	for _, entry := range h.entries {
+		writer.WriteString(entry + "\n")
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,41 @@
+package repl
+
+import (
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/token"
+	"strings"
+)
+
+// Incomplete checks if the input needs more lines to make
+// sense: a block without its END, an open bracket or an
+// unterminated string or comment.
+func Incomplete(source string) bool {
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	lex := lexer.New(reader.New([]byte(source)))
+	blocks, brackets := 0, 0
+
+	for tok := lex.NextToken(); tok.Type != token.EOF; tok = lex.NextToken() {
+		switch tok.Type {
+		case token.FUNCTION, token.IF, token.FOR, token.SWITCH, token.MODULE:
+			blocks++
+		case token.END:
+			blocks--
+		case token.LPAREN, token.LBRACK:
+			brackets++
+		case token.RPAREN, token.RBRACK:
+			brackets--
+		}
+	}
+
+	for _, report := range reporter.GetReports() {
+		if strings.HasPrefix(report.Message, "Unterminated") {
+			return true
+		}
+	}
+
+	return blocks > 0 || brackets > 0
+}
@@ -0,0 +1,235 @@
+// Package repl implements the interactive prompt: input over
+// multiple lines, history, completion and a few commands
+// starting with a colon.
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/interpreter"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fatih/color"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Prompts for a new input and for the lines
+// that continue it.
+const (
+	prompt         = ">> "
+	continuePrompt = ".. "
+)
+
+// Help printed by :help.
+const help = `Commands:
+  :help         Show this help
+  :type EXPR    Show the type of an expression
+  :reset        Forget everything declared so far
+  :load FILE    Run a source file in the current scope
+
+Blocks and brackets can span multiple lines. TAB completes
+names and module members, the arrow keys move in history.
+CTRL+C cancels the input and CTRL+D exits.`
+
+// REPL is a Read-Eval-Print Loop keeping its scope
+// between inputs.
+type REPL struct {
+	in      io.Reader
+	out     io.Writer
+	history *History
+	runner  *interpreter.Interpreter
+	scope   *interpreter.Scope
+}
+
+// New initializes a REPL.
+func New(in io.Reader, out io.Writer, history *History) *REPL {
+	r := &REPL{in: in, out: out, history: history}
+	r.reset()
+
+	return r
+}
+
+// Run reads and evaluates input until it ends. Terminals
+// get line editing, anything else is read line by line.
+func (r *REPL) Run() error {
+	defer r.history.Save()
+
+	readLine := r.plainReader()
+	if file, ok := r.in.(*os.File); ok {
+		if restore, err := makeRaw(file.Fd()); err == nil {
+			restore()
+			readLine = r.editorReader(file)
+		}
+	}
+
+	input := ""
+	for {
+		linePrompt := prompt
+		if input != "" {
+			linePrompt = continuePrompt
+		}
+
+		line, err := readLine(linePrompt)
+		if err == ErrInterrupted {
+			input = ""
+			continue
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		r.history.Add(line)
+
+		if input == "" && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			r.command(strings.TrimSpace(line))
+			continue
+		}
+
+		input += line + "\n"
+		if Incomplete(input) {
+			continue
+		}
+
+		r.Eval(input)
+		input = ""
+	}
+}
+
+// Read lines as they come, for input that
+// isn't a terminal.
+func (r *REPL) plainReader() func(string) (string, error) {
+	input := bufio.NewReader(r.in)
+
+	return func(linePrompt string) (string, error) {
+		fmt.Fprint(r.out, linePrompt)
+
+		line, err := input.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+
+		return strings.TrimRight(line, "\r\n"), err
+	}
+}
+
+// Read lines with the editor, in raw mode only for
+// as long as a line is being written.
+func (r *REPL) editorReader(file *os.File) func(string) (string, error) {
+	editor := NewEditor(file, r.out, r.history, r.complete)
+
+	return func(linePrompt string) (string, error) {
+		restore, err := makeRaw(file.Fd())
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+
+		return editor.ReadLine(linePrompt)
+	}
+}
+
+// Eval runs source code in the REPL's scope and
+// prints the result or the errors.
+func (r *REPL) Eval(source string) {
+	program := r.parse(source)
+	if program == nil {
+		return
+	}
+
+	object := r.runner.Interpret(program, r.scope)
+	if r.printErrors() {
+		return
+	}
+
+	if object != nil {
+		fmt.Fprintln(r.out, object.Inspect())
+	}
+}
+
+// Run a colon command.
+func (r *REPL) command(line string) {
+	name, argument := line, ""
+	if space := strings.IndexAny(line, " \t"); space != -1 {
+		name, argument = line[:space], strings.TrimSpace(line[space+1:])
+	}
+
+	switch name {
+	case ":help":
+		fmt.Fprintln(r.out, help)
+	case ":type":
+		// Evaluated in a child scope, so declarations
+		// don't stay around.
+		program := r.parse(argument)
+		if program == nil {
+			return
+		}
+
+		object := r.runner.Interpret(program, interpreter.NewScopeFrom(r.scope))
+		if !r.printErrors() && object != nil {
+			fmt.Fprintln(r.out, object.Type())
+		}
+	case ":reset":
+		r.reset()
+		fmt.Fprintln(r.out, "Scope cleared")
+	case ":load":
+		source, err := ioutil.ReadFile(argument)
+		if err != nil {
+			color.New(color.FgRed).Fprintf(r.out, "Couldn't read '%s'\n", argument)
+			return
+		}
+		r.Eval(string(source))
+	default:
+		color.New(color.FgRed).Fprintf(r.out, "Unknown command '%s', see :help\n", name)
+	}
+}
+
+// Start over with a new interpreter and scope.
+func (r *REPL) reset() {
+	r.runner = interpreter.New()
+	r.runner.SetOutput(r.out)
+	r.scope = interpreter.NewScope()
+
+	// Interpreting anything loads the Standard Library,
+	// so its modules can be completed right away.
+	r.runner.Interpret(&ast.Program{}, r.scope)
+}
+
+// Parse source code, printing any error.
+func (r *REPL) parse(source string) *ast.Program {
+	program := parser.New(lexer.New(reader.New([]byte(source)))).Parse()
+	if r.printErrors() {
+		return nil
+	}
+
+	return program
+}
+
+// Print and clear the reported errors, if any.
+func (r *REPL) printErrors() bool {
+	if !reporter.HasErrors() {
+		return false
+	}
+
+	for _, err := range reporter.GetErrors() {
+		color.New(color.FgRed).Fprintln(r.out, err)
+	}
+	reporter.ClearErrors()
+
+	return true
+}
+
+// Completion candidates from the scope, the runtime
+// and the modules.
+func (r *REPL) complete(word string) []string {
+	names := append(r.scope.Names(), interpreter.RuntimeFunctions()...)
+	return Complete(word, names, r.runner.Modules())
+}
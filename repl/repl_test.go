@@ -0,0 +1,154 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2", false},
+		{"let f = func (x)", true},
+		{"let f = func (x)\n  x\nend", false},
+		{"if a\n  for i in b", true},
+		{"if a\n  for i in b\n  end\nend", false},
+		{"[1, 2", true},
+		{"add(1,", true},
+		{`"unterminated`, true},
+		{"/* open comment", true},
+		{"let f = (x) -> x", false},
+	}
+
+	for _, test := range tests {
+		if actual := Incomplete(test.input); actual != test.expected {
+			t.Errorf("Expected %t for %q but got %t", test.expected, test.input, actual)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	modules := map[string][]string{"Enum": {"map", "max", "size"}, "Math": {"pi"}}
+	names := []string{"maximum", "total"}
+
+	tests := []struct {
+		word     string
+		expected []string
+	}{
+		{"Enum.m", []string{"Enum.map", "Enum.max"}},
+		{"Enum.s", []string{"Enum.size"}},
+		{"Nope.a", []string{}},
+		{"M", []string{"Math"}},
+		{"ma", []string{"maximum"}},
+		{"mo", []string{"module"}},
+	}
+
+	for _, test := range tests {
+		actual := Complete(test.word, names, modules)
+		if strings.Join(actual, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Expected %v for %q but got %v", test.expected, test.word, actual)
+		}
+	}
+
+	if prefix := commonPrefix([]string{"Enum.map", "Enum.max"}); prefix != "Enum.ma" {
+		t.Errorf("Expected %q but got %q", "Enum.ma", prefix)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "history")
+	history := NewHistory(file)
+	history.Add("let a = 1")
+	history.Add("let a = 1")
+	history.Add("  ")
+	history.Add("a + 1")
+
+	if err := history.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := NewHistory(file).Entries()
+	if len(entries) != 2 || entries[0] != "let a = 1" || entries[1] != "a + 1" {
+		t.Errorf("Expected the saved entries but got %v", entries)
+	}
+}
+
+func TestEditor(t *testing.T) {
+	history := NewHistory("")
+	history.Add("let a = 1")
+
+	complete := func(word string) []string {
+		return Complete(word, []string{"total"}, map[string][]string{"Enum": {"size"}})
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"abc\r", "abc"},
+		{"abc\x7f\x7fd\r", "ad"},
+		{"ac\x1b[Db\r", "abc"},
+		{"bc\x01a\x05d\r", "abcd"},
+		{"\x1b[A\r", "let a = 1"},
+		{"x\x1b[A\x1b[B\r", "x"},
+		{"to\t + 1\r", "total + 1"},
+		{"Enum.s\t(a)\r", "Enum.size(a)"},
+		{"abc\x01\x1b[3~\r", "bc"},
+		{"abc\x02\x0b\r", "ab"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		editor := NewEditor(strings.NewReader(test.keys), &out, history, complete)
+
+		line, err := editor.ReadLine(">> ")
+		if err != nil {
+			t.Errorf("Expected a line for %q but got %s", test.keys, err)
+			continue
+		}
+
+		if line != test.expected {
+			t.Errorf("Expected %q but got %q", test.expected, line)
+		}
+	}
+
+	editor := NewEditor(strings.NewReader("\x04"), ioutil.Discard, history, complete)
+	if _, err := editor.ReadLine(">> "); err != io.EOF {
+		t.Errorf("Expected EOF on CTRL+D but got %v", err)
+	}
+
+	editor = NewEditor(strings.NewReader("abc\x03"), ioutil.Discard, history, complete)
+	if _, err := editor.ReadLine(">> "); err != ErrInterrupted {
+		t.Errorf("Expected an interruption on CTRL+C but got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	input := "let f = func (x)\n  x * 2\nend\nf(3)\n:type f\n:reset\n:type f\n"
+
+	var out bytes.Buffer
+	r := New(strings.NewReader(input), &out, NewHistory(""))
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"6\n", "Function\n", "Scope cleared\n", "Identifier 'f' not found"}
+	for _, str := range expected {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("Expected %q in the output but got %q", str, out.String())
+		}
+	}
+}
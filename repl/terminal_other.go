@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package repl
+
+import "errors"
+
+// Line editing isn't supported, so input is
+// read as plain lines.
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("line editing is not supported on this platform")
+}
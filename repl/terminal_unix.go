@@ -0,0 +1,45 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package repl
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Put the terminal in raw mode, so keys are read as they're
+// pressed and without echo. The returned function brings
+// the terminal back to its previous state.
+func makeRaw(fd uintptr) (func(), error) {
+	var original syscall.Termios
+	if err := ioctlTermios(fd, ioctlReadTermios, &original); err != nil {
+		return nil, err
+	}
+
+	raw := original
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctlTermios(fd, ioctlWriteTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ioctlTermios(fd, ioctlWriteTermios, &original)
+	}, nil
+}
+
+// Read or write the terminal settings.
+func ioctlTermios(fd uintptr, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -14,12 +14,22 @@ const (
 	RUNTIME ErrorType = "Runtime Error"
 )
 
+// Report is an error as it was reported, for tools
+// that need its parts instead of the formatted message.
+type Report struct {
+	Type     ErrorType
+	Location token.Location
+	Message  string
+}
+
 // Error store.
 var errors []string
+var reports []Report
 
 // Error adds a new error to the store.
 func Error(errortype ErrorType, location token.Location, message string) {
 	errors = append(errors, fmt.Sprintf("%s [Line %d:%d]: %s", errortype, location.Row, location.Col, message))
+	reports = append(reports, Report{Type: errortype, Location: location, Message: message})
 }
 
 // HasErrors checks if there are errors.
@@ -32,7 +42,28 @@ func GetErrors() []string {
 	return errors
 }
 
+// CountErrors returns the number of errors.
+func CountErrors() int {
+	return len(errors)
+}
+
+// TruncateErrors keeps only the first n errors, dropping
+// those the program handled itself.
+func TruncateErrors(n int) {
+	if n < len(errors) {
+		errors = errors[:n]
+		reports = reports[:n]
+	}
+}
+
+// GetReports returns the errors with their type
+// and location.
+func GetReports() []Report {
+	return reports
+}
+
 // ClearErrors clears the errors.
 func ClearErrors() {
 	errors = []string{}
+	reports = []Report{}
 }
@@ -33,6 +33,33 @@ func TestGetErrors(t *testing.T) {
 	}
 }
 
+func TestGetReports(t *testing.T) {
+	ClearErrors()
+	Error(RUNTIME, token.Location{Row: 3, Col: 5}, "Test error")
+
+	reports := GetReports()
+	if len(reports) != 1 {
+		t.Fatalf("Expected %d but got %d", 1, len(reports))
+	}
+
+	expected := Report{Type: RUNTIME, Location: token.Location{Row: 3, Col: 5}, Message: "Test error"}
+	if reports[0] != expected {
+		t.Errorf("Expected %v but got %v", expected, reports[0])
+	}
+}
+
+func TestTruncateErrors(t *testing.T) {
+	ClearErrors()
+	Error(PARSE, token.Location{Row: 1, Col: 1}, "Test error 1")
+	Error(PARSE, token.Location{Row: 2, Col: 1}, "Test error 2")
+
+	TruncateErrors(1)
+
+	if CountErrors() != 1 || len(GetReports()) != 1 {
+		t.Errorf("Expected %d but got %d", 1, CountErrors())
+	}
+}
+
 func TestClearErrors(t *testing.T) {
 	errors = []string{}
 	Error(PARSE, token.Location{1, 1}, "Test error 1")
@@ -40,7 +67,7 @@ func TestClearErrors(t *testing.T) {
 
 	ClearErrors()
 
-	if len(errors) != 0 {
+	if len(errors) != 0 || len(reports) != 0 {
 		t.Errorf("Expected %d but got %d", 0, len(errors))
 	}
 }
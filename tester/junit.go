@@ -0,0 +1,91 @@
+package tester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit writes the results as JUnit XML, with a test
+// suite for every file.
+func JUnit(w io.Writer, results []Result) error {
+	out := junitSuites{}
+	suites := map[string]int{}
+	durations := []time.Duration{}
+
+	for _, result := range results {
+		index, ok := suites[result.File]
+		if !ok {
+			index = len(out.Suites)
+			suites[result.File] = index
+			out.Suites = append(out.Suites, junitSuite{Name: result.File})
+			durations = append(durations, 0)
+		}
+
+		suite := &out.Suites[index]
+		testcase := junitCase{
+			Name:      result.Name,
+			Classname: result.File,
+			Time:      seconds(result.Duration.Seconds()),
+		}
+
+		if !result.Passed() {
+			testcase.Failure = &junitFailure{
+				Message: result.Failures[0],
+				Text:    strings.Join(result.Failures, "\n"),
+			}
+			suite.Failures++
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testcase)
+
+		durations[index] += result.Duration
+		suite.Time = seconds(durations[index].Seconds())
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Format seconds as JUnit expects them.
+func seconds(value float64) string {
+	return fmt.Sprintf("%.3f", value)
+}
@@ -0,0 +1,195 @@
+// Package tester runs the tests of source files. Tests are
+// top-level functions without parameters whose name starts
+// with "test", each run in its own interpreter.
+package tester
+
+import (
+	"fmt"
+	"github.com/fadion/aria/ast"
+	"github.com/fadion/aria/interpreter"
+	"github.com/fadion/aria/lexer"
+	"github.com/fadion/aria/parser"
+	"github.com/fadion/aria/reader"
+	"github.com/fadion/aria/reporter"
+	"github.com/fadion/aria/token"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of a single test.
+type Result struct {
+	File     string
+	Name     string
+	Failures []string
+	Duration time.Duration
+}
+
+// Passed checks if the test ran without errors.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// IsTestFile checks if a file holds tests, by
+// its name.
+func IsTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.ari")
+}
+
+// RunFile runs the tests of a source file.
+func RunFile(path string) ([]Result, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't read '%s'", path)
+	}
+
+	return RunSource(path, source)
+}
+
+// RunSource runs the tests of source code under
+// the given file name.
+func RunSource(name string, source []byte) ([]Result, error) {
+	program, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse '%s': %s", name, err)
+	}
+
+	results := []Result{}
+	for _, test := range findTests(program) {
+		results = append(results, run(name, source, test))
+	}
+
+	return results, nil
+}
+
+// Parse source code, returning the errors as one.
+func parse(source []byte) (*ast.Program, error) {
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	program := parser.New(lexer.New(reader.New(source))).Parse()
+	if reporter.HasErrors() {
+		return nil, fmt.Errorf("%s", strings.Join(reporter.GetErrors(), "; "))
+	}
+
+	return program, nil
+}
+
+// Names of the test functions, in the order
+// they're declared.
+func findTests(program *ast.Program) []string {
+	tests := []string{}
+
+	for _, statement := range program.Statements {
+		expression, ok := statement.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+
+		let, ok := expression.Expression.(*ast.Let)
+		if !ok || !strings.HasPrefix(let.Name.Value, "test") {
+			continue
+		}
+
+		if function, ok := let.Value.(*ast.Function); ok && len(function.Parameters) == 0 {
+			tests = append(tests, let.Name.Value)
+		}
+	}
+
+	return tests
+}
+
+// Run a test in a new interpreter, after the top-level
+// code of its file. The source is parsed again, so
+// nothing is shared with other tests.
+func run(file string, source []byte, name string) Result {
+	start := time.Now()
+	result := Result{File: file, Name: name}
+
+	program, err := parse(source)
+	if err != nil {
+		result.Failures = []string{err.Error()}
+		return result
+	}
+
+	reporter.ClearErrors()
+	defer reporter.ClearErrors()
+
+	scope := interpreter.NewScope()
+	runner := interpreter.New()
+	runner.SetFile(file)
+	tracer := &failureTracer{runner: runner}
+	runner.SetTracer(tracer)
+
+	runner.Interpret(program, scope)
+
+	if !reporter.HasErrors() {
+		identifier := token.Token{Type: token.IDENTIFIER, Lexeme: name}
+		runner.Interpret(&ast.FunctionCall{
+			Token:     identifier,
+			Function:  &ast.Identifier{Token: identifier, Value: name},
+			Arguments: &ast.ExpressionList{},
+		}, scope)
+	}
+
+	tracer.locate()
+	for index, report := range reporter.GetReports() {
+		at := tracer.locations[index]
+		if at.Row == 0 {
+			at = report.Location
+		}
+		result.Failures = append(result.Failures, fmt.Sprintf("%s:%d:%d: %s", file, at.Row, at.Col, report.Message))
+	}
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+// failureTracer keeps, for every error reported, the
+// statement of the tested file that was running. Failed
+// assertions are reported inside the Standard Library,
+// so their own location doesn't help.
+type failureTracer struct {
+	runner    *interpreter.Interpreter
+	locations []token.Location
+}
+
+// Step is part of interpreter.Tracer.
+func (f *failureTracer) Step() {
+	f.locate()
+}
+
+// Enter is part of interpreter.Tracer.
+func (f *failureTracer) Enter(frame interpreter.Frame) {
+	f.locate()
+}
+
+// Leave is part of interpreter.Tracer.
+func (f *failureTracer) Leave(frame interpreter.Frame) {
+	f.locate()
+}
+
+// Give the errors reported since the last call the
+// location of the innermost frame outside the Standard
+// Library.
+func (f *failureTracer) locate() {
+	// Errors the program handled itself are dropped.
+	count := reporter.CountErrors()
+	if len(f.locations) >= count {
+		f.locations = f.locations[:count]
+		return
+	}
+
+	var at token.Location
+	frames := f.runner.Frames()
+	for index := len(frames) - 1; index >= 0; index-- {
+		if frames[index].File != interpreter.LibraryFile {
+			at = frames[index].Location
+			break
+		}
+	}
+
+	for len(f.locations) < count {
+		f.locations = append(f.locations, at)
+	}
+}
@@ -0,0 +1,105 @@
+package tester
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunSource(t *testing.T) {
+	input := `let double = func (x) do x * 2 end
+var calls = 0
+
+let testDouble = func ()
+  calls += 1
+  Assert.equal(double(2), 4)
+  Assert.equal(calls, 1)
+end
+
+let testFails = func ()
+  calls += 1
+  Assert.equal(calls, 1)
+  Assert.equal(double(2), 5)
+end
+
+let testWithParameters = func (x) do x end
+let helper = func () do 1 end`
+
+	results, err := RunSource("double_test.ari", []byte(input))
+	if err != nil {
+		t.Fatalf("Expected tests to run but got %s", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected %d results but got %d", 2, len(results))
+	}
+
+	if results[0].Name != "testDouble" || !results[0].Passed() {
+		t.Errorf("Expected testDouble to pass but got %v", results[0])
+	}
+
+	if results[1].Name != "testFails" || results[1].Passed() {
+		t.Errorf("Expected testFails to fail but got %v", results[1])
+	}
+
+	if len(results[1].Failures) == 0 || results[1].Failures[0] != "double_test.ari:13:3: Expected 5 but got 4" {
+		t.Errorf("Expected the assertion message but got %v", results[1].Failures)
+	}
+}
+
+func TestRunSourceErrors(t *testing.T) {
+	if _, err := RunSource("broken_test.ari", []byte("let = 1")); err == nil {
+		t.Errorf("Expected a parse error")
+	}
+
+	results, err := RunSource("setup_test.ari", []byte("missing()\nlet testA = func () do 1 end"))
+	if err != nil {
+		t.Fatalf("Expected tests to run but got %s", err)
+	}
+
+	if len(results) != 1 || results[0].Passed() {
+		t.Errorf("Expected failing setup to fail the test but got %v", results)
+	}
+
+	// Errors the test handles itself don't move the
+	// location of those that follow.
+	input := "let testRaises = func ()\n  Assert.raises(func () do 1 / 0 end)\n  Assert.truthy(false)\nend"
+	results, err = RunSource("raises_test.ari", []byte(input))
+	if err != nil {
+		t.Fatalf("Expected tests to run but got %s", err)
+	}
+
+	if len(results) != 1 || len(results[0].Failures) != 1 || !strings.HasPrefix(results[0].Failures[0], "raises_test.ari:3:3: ") {
+		t.Errorf("Expected the failure at line 3 but got %v", results)
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	if !IsTestFile("math_test.ari") || IsTestFile("math.ari") {
+		t.Errorf("Expected only files ending in _test.ari to be test files")
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	results := []Result{
+		{File: "a_test.ari", Name: "testOne"},
+		{File: "a_test.ari", Name: "testTwo", Failures: []string{"Expected 1 but got 2"}},
+	}
+
+	var out bytes.Buffer
+	if err := JUnit(&out, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`<testsuite name="a_test.ari" tests="2" failures="1" time="0.000">`,
+		`<testcase name="testOne" classname="a_test.ari" time="0.000"></testcase>`,
+		`<failure message="Expected 1 but got 2">Expected 1 but got 2</failure>`,
+	}
+
+	for _, str := range expected {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("Expected %q in %s", str, out.String())
+		}
+	}
+}
@@ -2,15 +2,15 @@ package token
 
 // Token represents a language token.
 type Token struct {
-	Type     TokenType
-	Lexeme   string
-	Location Location
+	Type     TokenType `json:"type"`
+	Lexeme   string    `json:"lexeme"`
+	Location Location  `json:"location"`
 }
 
 // Location of the token in source code.
 type Location struct {
-	Row int
-	Col int
+	Row int `json:"row"`
+	Col int `json:"col"`
 }
 
 // TokenType is a type of token aliased
@@ -23,7 +23,9 @@ const (
 	IDENTIFIER = "IDENTIFIER"
 	INTEGER    = "INTEGER"
 	FLOAT      = "FLOAT"
+	DECIMAL    = "DECIMAL"
 	STRING     = "STRING"
+	REGEX      = "REGEX"
 	BOOLEAN    = "BOOLEAN"
 
 	// Operators
@@ -63,6 +65,7 @@ const (
 	RPAREN     = ")"
 	NEWLINE    = "\\n"
 	LBRACK     = "["
+	SETBRACK   = "#["
 	RBRACK     = "]"
 	COLON      = ":"
 	RANGE      = ".."
@@ -0,0 +1,67 @@
+// Package wire reads and writes JSON messages framed
+// by a Content-Length header, as used by the Language
+// Server and Debug Adapter protocols.
+package wire
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Read reads the body of a message.
+func Read(in *bufio.Reader) ([]byte, error) {
+	length := -1
+
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		if colon := strings.Index(line, ":"); colon != -1 {
+			name := strings.TrimSpace(line[:colon])
+			if strings.EqualFold(name, "Content-Length") {
+				length, err = strconv.Atoi(strings.TrimSpace(line[colon+1:]))
+				if err != nil {
+					return nil, fmt.Errorf("invalid Content-Length '%s'", line[colon+1:])
+				}
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(in, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// Write encodes a message as JSON and writes it
+// with its header.
+func Write(out io.Writer, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+
+	_, err = out.Write(body)
+	return err
+}